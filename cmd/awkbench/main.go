@@ -6,29 +6,86 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/kolkov/uawk-bench/internal/dataset"
 	"github.com/kolkov/uawk-bench/internal/report"
 	"github.com/kolkov/uawk-bench/internal/runner"
+	"github.com/kolkov/uawk-bench/internal/stats"
+	"github.com/kolkov/uawk-bench/internal/sweep"
+	"github.com/kolkov/uawk-bench/internal/verify"
 )
 
 var (
-	dataDir     = flag.String("data", "testdata", "Directory for test data")
-	programDir  = flag.String("programs", "programs", "Directory with AWK programs")
-	outputDir   = flag.String("output", "results", "Directory for results")
-	size        = flag.String("size", "10MB", "Dataset size: 1MB, 10MB, 100MB, 500MB")
-	runs        = flag.Int("runs", 5, "Number of benchmark runs")
-	warmup      = flag.Int("warmup", 1, "Number of warmup runs")
-	awkList     = flag.String("awk", "", "Comma-separated list of AWKs to test (default: all available)")
+	dataDir      = flag.String("data", "testdata", "Directory for test data")
+	programDir   = flag.String("programs", "programs", "Directory with AWK programs")
+	outputDir    = flag.String("output", "results", "Directory for results")
+	size         = flag.String("size", "10MB", "Dataset size: 1MB, 10MB, 100MB, 500MB")
+	runs         = flag.Int("runs", 5, "Number of benchmark runs")
+	warmup       = flag.Int("warmup", 1, "Number of warmup runs")
+	awkList      = flag.String("awk", "", "Comma-separated list of AWKs to test (default: all available)")
 	generateOnly = flag.Bool("generate", false, "Only generate test data, don't benchmark")
-	format      = flag.String("format", "markdown", "Output format: markdown, json, csv")
+	format       = flag.String("format", "markdown", "Output format: markdown, json, csv")
+	verifyOutput = flag.Bool("verify", true, "Verify outputs against a reference before timing")
+	reference    = flag.String("reference", "gawk", "Reference AWK for output verification")
+	goldenDir    = flag.String("golden", "", "Directory with golden outputs (<dir>/<size>/<program>.out)")
+	schedule     = flag.String("schedule", "sequential", "Run order across AWKs: sequential, roundrobin, shuffle")
+	scope        = flag.String("scope", "program", "Interleave runs within each program or across the whole matrix: program, matrix")
+	seed         = flag.Int64("seed", 0, "Seed for the shuffle schedule (0 = random, printed and recorded)")
+	targetCI     = flag.Float64("target-ci", 0, "Adaptive runs: target relative CI half-width of the median, e.g. 0.02 (0 = fixed -runs)")
+	minRuns      = flag.Int("min-runs", 0, "Adaptive runs: minimum runs per cell (default: -runs)")
+	maxRuns      = flag.Int("max-runs", 100, "Adaptive runs: maximum runs per cell")
+	cpuList      = flag.String("cpus", "", "Pin AWK processes to CPUs from this list, e.g. 2-5 (Linux; -j variants get N CPUs)")
+	inputModes   = flag.String("input-modes", "file", "Comma-separated input delivery modes: file, stdin, pipe, fifo, list")
+	layoutList   = flag.String("layouts", "single", "Comma-separated input layouts: single (one file), shards (-shards files), small (files of -small-file-size bytes)")
+	shardCount   = flag.Int("shards", 4, "Number of shards per dataset in the shards layout")
+	smallSize    = flag.Int("small-file-size", 1024, "Bytes per file in the small layout (10MB -> ~10k files)")
+	cacheMode    = flag.String("cache", "default", "Page cache mode for inputs: default, hot (preload + mincore check), cold (evict before every run)")
+	nice         = flag.Int("nice", 0, "Nice value for AWK processes (negative needs privileges)")
+	outliers     = flag.String("outliers", "tukey", "Outlier classification: tukey, mad, none")
+	robust       = flag.Bool("robust", false, "Compute statistics excluding outliers (raw samples are kept)")
+	onFailure    = flag.String("on-failure", "abort", "When a run fails (timeout, crash, non-zero exit): abort the cell, retry it (-retries times), or continue")
+	retries      = flag.Int("retries", 2, "Repeats of a failed run with -on-failure retry")
+	timeout      = flag.Duration("timeout", 5*time.Minute, "Time limit per run")
+	localeList   = flag.String("locales", "C", "Comma-separated LC_ALL values to run the suite under, e.g. C,C.UTF-8")
+	inheritEnv   = flag.Bool("inherit-env", false, "Pass the full harness environment to AWKs (default: PATH, HOME and temp dirs only)")
+	grace        = flag.Duration("grace", 2*time.Second, "Time between SIGTERM and SIGKILL to an AWK's process group on timeout or Ctrl-C")
+	startup      = flag.Bool("startup", false, "Measure each AWK's startup cost (BEGIN{} without input, per locale) and a /bin/true baseline; adds a cell per AWK and locale")
+	net          = flag.Bool("net", false, "Also report net time per cell with the AWK's startup cost subtracted (implies -startup)")
+	sweepSpecs   = flag.String("sweep", "", "Semicolon-separated sweep specs, e.g. 'uawk × {posix,--no-posix} × GOGC={50,100,off}' (replaces the default AWK list)")
+	scaling      = flag.String("scaling", "", "Scaling curves: comma-separated knobs (j, gomaxprocs) to run the -awk AWKs (default uawk) at 1..-scaling-max, pinned to that many CPUs")
+	scalingMax   = flag.Int("scaling-max", 0, "Largest value of the scaling knobs (default: the -cpus count or all CPUs)")
+	counters     = flag.Bool("counters", false, "Collect performance counters per run: cycles, instructions, branch and cache misses, task clock (Linux perf_event_open)")
+	sampleEvery  = flag.Duration("sample-interval", 0, "Sample each AWK's RSS and I/O from /proc at this interval, e.g. 10ms (Linux; 0 = off)")
+	slowdown     = flag.Float64("slowdown-threshold", 0.2, "Flag cells whose read throughput drops by more than this fraction from the first to the last quarter (needs -sample-interval)")
+	gcTrace      = flag.Bool("gctrace", false, "Trace the GC of Go AWKs (uawk, goawk) with GODEBUG=gctrace=1 and report GC count, pauses, heap goal and GC CPU")
+	profileKinds = flag.String("profile-kinds", "cpu", "awkbench profile: comma-separated profile kinds (cpu, mem)")
+	profileTop   = flag.Int("profile-top", 10, "awkbench profile: functions in each profile summary")
+	profileWrap  = flag.String("profile-wrapper", "", "awkbench profile: command prefix for AWKs without a pprof recipe, {} = output file (e.g. 'perf record -o {} --')")
+	cellBudget   = flag.Duration("cell-budget", time.Minute, "Adaptive runs: measured time budget per cell")
 )
 
+// profileMode is set by the profile subcommand: after timing, every cell
+// runs once more with profiling enabled.
+var profileMode bool
+
 func main() {
-	flag.Parse()
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [profile] [flags]\n\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "The profile subcommand also captures a profile of every cell into <output>/profiles.\n\n")
+		flag.PrintDefaults()
+	}
+	if len(os.Args) > 1 && os.Args[1] == "profile" {
+		profileMode = true
+		flag.CommandLine.Parse(os.Args[2:])
+	} else {
+		flag.Parse()
+	}
 
 	if err := run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -52,11 +109,21 @@ func run() error {
 	}
 	fmt.Printf("Generated %d datasets in %s\n", len(files), *dataDir)
 
+	layouts, err := inputLayouts(gen, files)
+	if err != nil {
+		return err
+	}
+
 	if *generateOnly {
 		for name, path := range files {
 			info, _ := os.Stat(path)
 			fmt.Printf("  %s: %s (%.1f MB)\n", name, path, float64(info.Size())/(1<<20))
 		}
+		for _, l := range layouts {
+			if len(l.files["numeric"]) > 1 {
+				fmt.Printf("  %s: %s\n", l.name, filepath.Dir(l.files["numeric"][0]))
+			}
+		}
 		return nil
 	}
 
@@ -64,27 +131,27 @@ func run() error {
 	// Note: frawk skipped - Cranelift backend crashes, LLVM requires complex CI setup
 	numCPU := runtime.NumCPU()
 	allAWKs := []runner.AWK{
-		{Name: "uawk", Command: "uawk"},                                    // POSIX mode (default)
-		{Name: "uawk-fast", Command: "uawk", Args: []string{"--no-posix"}}, // Fast mode
-		{Name: "goawk", Command: "goawk"},
-		{Name: "gawk", Command: "gawk", Args: []string{"-b"}},
+		{Name: "uawk", Command: "uawk", GoRuntime: true, Profiler: runner.GoProfiler},                                    // POSIX mode (default)
+		{Name: "uawk-fast", Command: "uawk", Args: []string{"--no-posix"}, GoRuntime: true, Profiler: runner.GoProfiler}, // Fast mode
+		{Name: "goawk", Command: "goawk", GoRuntime: true, Profiler: runner.GoProfiler},
+		{Name: "gawk", Command: "gawk"}, // Byte or multibyte mode follows -locales
 		{Name: "mawk", Command: "mawk"},
 	}
 
 	// Add parallel modes if more than 1 CPU
 	if numCPU >= 2 {
 		allAWKs = append(allAWKs, runner.AWK{
-			Name: "uawk-j2", Command: "uawk", Args: []string{"-j", "2"},
+			Name: "uawk-j2", Command: "uawk", Args: []string{"-j", "2"}, GoRuntime: true, Profiler: runner.GoProfiler,
 		})
 	}
 	if numCPU >= 4 {
 		allAWKs = append(allAWKs, runner.AWK{
-			Name: "uawk-j4", Command: "uawk", Args: []string{"-j", "4"},
+			Name: "uawk-j4", Command: "uawk", Args: []string{"-j", "4"}, GoRuntime: true, Profiler: runner.GoProfiler,
 		})
 	}
 
 	var awks []runner.AWK
-	if *awkList != "" {
+	if *awkList != "" && *scaling == "" {
 		// Filter to requested AWKs
 		requested := strings.Split(*awkList, ",")
 		for _, awk := range allAWKs {
@@ -95,9 +162,23 @@ func run() error {
 				}
 			}
 		}
-	} else {
+	} else if *sweepSpecs == "" && *scaling == "" {
 		awks = runner.FindAvailable(allAWKs)
 	}
+	if *sweepSpecs != "" {
+		variants, err := sweepAWKs(*sweepSpecs, allAWKs)
+		if err != nil {
+			return err
+		}
+		awks = append(awks, variants...)
+	}
+	if *scaling != "" {
+		variants, err := scalingAWKs(allAWKs)
+		if err != nil {
+			return err
+		}
+		awks = append(awks, variants...)
+	}
 
 	if len(awks) == 0 {
 		return fmt.Errorf("no AWK implementations found")
@@ -121,62 +202,308 @@ func run() error {
 		return fmt.Errorf("no AWK programs found in %s", *programDir)
 	}
 
-	fmt.Printf("Running %d programs with %d runs each...\n\n", len(programs), *runs)
+	if *targetCI > 0 {
+		fmt.Printf("Running %d programs until the median is within ±%.1f%% (max %d runs)...\n\n", len(programs), *targetCI*100, *maxRuns)
+	} else {
+		fmt.Printf("Running %d programs with %d runs each...\n\n", len(programs), *runs)
+	}
 
 	// Setup runner
 	r := runner.NewRunner()
 	r.Runs = *runs
 	r.Warmup = *warmup
+	r.Schedule, err = runner.ParseSchedule(*schedule)
+	if err != nil {
+		return err
+	}
+	if *scope != "program" && *scope != "matrix" {
+		return fmt.Errorf("invalid scope: %s (use program, matrix)", *scope)
+	}
+	if *cpuList != "" {
+		r.CPUs, err = runner.ParseCPUList(*cpuList)
+		if err != nil {
+			return err
+		}
+	}
+	if *scaling != "" && len(r.CPUs) == 0 {
+		// Pin scaling variants within the harness's own CPUs
+		r.CPUs, err = runner.AllowedCPUs()
+		if err != nil {
+			return fmt.Errorf("reading CPU affinity: %w", err)
+		}
+	}
+	r.Nice = *nice
+	r.SampleInterval = *sampleEvery
+	r.SlowdownThreshold = *slowdown
+	r.GCTrace = *gcTrace
+	var kinds []runner.ProfileKind
+	if profileMode {
+		if kinds, err = runner.ParseProfileKinds(*profileKinds); err != nil {
+			return err
+		}
+		r.ProfileTop = *profileTop
+		r.ProfileWrapper = strings.Fields(*profileWrap)
+		if err := os.MkdirAll(filepath.Join(*outputDir, "profiles"), 0755); err != nil {
+			return fmt.Errorf("creating profile dir: %w", err)
+		}
+	}
+	if *counters {
+		unavailable, err := runner.ProbeCounters()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; continuing without counters\n", err)
+		} else {
+			r.Counters = true
+			if len(unavailable) > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: performance counters unavailable: %s\n", strings.Join(unavailable, ", "))
+			}
+		}
+	}
+	modes, err := runner.ParseInputModes(*inputModes)
+	if err != nil {
+		return err
+	}
+	if len(modes) == 0 {
+		return fmt.Errorf("no input modes given")
+	}
+	r.CacheMode, err = runner.ParseCacheMode(*cacheMode)
+	if err != nil {
+		return err
+	}
+	r.OutlierMethod, err = stats.ParseOutlierMethod(*outliers)
+	if err != nil {
+		return err
+	}
+	r.ExcludeOutliers = *robust
+	r.OnFailure, err = runner.ParseFailurePolicy(*onFailure)
+	if err != nil {
+		return err
+	}
+	r.Retries = *retries
+	r.Timeout = *timeout
+	r.Grace = *grace
+	r.InheritEnv = *inheritEnv
+	var locales []string
+	for _, l := range strings.Split(*localeList, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			locales = append(locales, l)
+		}
+	}
+	if len(locales) == 0 {
+		return fmt.Errorf("no locales given")
+	}
+	r.Locale = locales[0] // Default for runs outside the matrix
+	r.TargetCI = *targetCI
+	r.MinRuns = *minRuns
+	r.MaxRuns = *maxRuns
+	r.Budget = *cellBudget
+	r.Seed = *seed
+	if r.Seed == 0 {
+		r.Seed = time.Now().UnixNano()
+	}
+	if r.Schedule == runner.Shuffle {
+		fmt.Printf("Shuffle seed: %d\n\n", r.Seed)
+	}
+
+	// Ctrl-C stops the running AWKs (each leads its own process group, so
+	// the terminal does not signal them) and writes partial results; a
+	// second Ctrl-C exits immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
-	ctx := context.Background()
 	var results []runner.BenchmarkResult
 
-	// Map programs to appropriate data files
+	// Setup output verification
+	var verifier *verify.Verifier
+	if *verifyOutput {
+		verifier = newVerifier(r, allAWKs, awks)
+	}
+
+	// Map programs to appropriate datasets
 	programData := map[string]string{
-		"sum.awk":         files["numeric"],
-		"count.awk":       files["text"],
-		"filter.awk":      files["numeric"],
-		"select.awk":      files["numeric"],
-		"groupby.awk":     files["keyvalue"],
-		"wordcount.awk":   files["text"],
-		"regex.awk":       files["text"],
-		"csv.awk":         files["csv"],
-		"ipaddr.awk":      files["log"],      // coregex: DigitPrefilter
-		"alternation.awk": files["log"],      // coregex: Aho-Corasick
-		"email.awk":       files["text"],     // coregex: char class with special chars
-		"suffix.awk":      files["log"],      // coregex: reverse search
-		"version.awk":     files["log"],      // coregex: digit sequences
-		"charclass.awk":   files["text"],     // uawk: CharClassSearcher fast path
-		"inner.awk":       files["log"],      // coregex: inner literal optimization
-		"anchored.awk":    files["log"],      // coregex: start anchor
+		"sum.awk":         "numeric",
+		"count.awk":       "text",
+		"filter.awk":      "numeric",
+		"select.awk":      "numeric",
+		"groupby.awk":     "keyvalue",
+		"wordcount.awk":   "text",
+		"regex.awk":       "text",
+		"csv.awk":         "csv",
+		"ipaddr.awk":      "log",  // coregex: DigitPrefilter
+		"alternation.awk": "log",  // coregex: Aho-Corasick
+		"email.awk":       "text", // coregex: char class with special chars
+		"suffix.awk":      "log",  // coregex: reverse search
+		"version.awk":     "log",  // coregex: digit sequences
+		"charclass.awk":   "text", // uawk: CharClassSearcher fast path
+		"inner.awk":       "log",  // coregex: inner literal optimization
+		"anchored.awk":    "log",  // coregex: start anchor
+		"perfile.awk":     "text", // per-file overhead (-layouts small)
+		"files.awk":       "text", // per-file overhead (-layouts small)
 	}
 
-	// Run benchmarks
+	// Collect benchmark cells per program
+	type check struct {
+		program string
+		mode    runner.InputMode
+		layout  string
+		locale  string
+	}
+	var groups [][]runner.Cell
+	failures := make(map[check]map[string]error) // program, input mode, layout and locale -> AWK -> error
 	for _, prog := range programs {
 		progName := filepath.Base(prog)
-		dataFile, ok := programData[progName]
+		data, ok := programData[progName]
 		if !ok {
-			dataFile = files["numeric"] // Default
+			data = "numeric" // Default
 		}
 
-		info, _ := os.Stat(dataFile)
-		inputSize := info.Size()
+		var cells []runner.Cell
+		for _, l := range layouts {
+			inputs := l.files[data]
+			inputSize := totalSize(inputs)
 
-		fmt.Printf("%-20s ", progName)
+			for _, locale := range locales {
+				for _, mode := range modes {
+					if mode == runner.InputStdin && len(inputs) > 1 {
+						continue // stdin takes a single file
+					}
+					for _, awk := range awks {
+						cells = append(cells, runner.Cell{
+							AWK:       awk,
+							Program:   prog,
+							Inputs:    inputs,
+							InputSize: inputSize,
+							InputMode: mode,
+							Layout:    l.name,
+							Locale:    locale,
+						})
+					}
+				}
+			}
+		}
+		if *scope == "matrix" && len(groups) > 0 {
+			groups[0] = append(groups[0], cells...)
+		} else {
+			groups = append(groups, cells)
+		}
+	}
 
-		for _, awk := range awks {
-			result, err := r.Benchmark(ctx, awk, prog, dataFile, inputSize)
+	// Verify outputs once per program, input mode, layout and locale
+	// before timing is trusted
+	for _, cells := range groups {
+		for _, c := range cells {
+			key := check{c.Program, c.InputMode, c.Layout, c.Locale}
+			if _, done := failures[key]; done || verifier == nil {
+				continue
+			}
+			if r.CheckArgs(c) != nil {
+				continue // Over ARG_MAX: the cell fails without running
+			}
+			var variant []string
+			if len(modes) > 1 {
+				variant = append(variant, string(c.InputMode))
+			}
+			if len(layouts) > 1 {
+				variant = append(variant, c.Layout)
+			}
+			if len(locales) > 1 {
+				variant = append(variant, c.Locale)
+			}
+			label := filepath.Base(c.Program)
+			if len(variant) > 0 {
+				label += " (" + strings.Join(variant, ", ") + ")"
+			}
+			progFailures, source, err := withSettings(verifier, c.Locale, c.InputMode).Check(ctx, awks, c.Program, c.Inputs...)
 			if err != nil {
-				fmt.Printf("%s:ERR ", awk.Name)
-				fmt.Fprintf(os.Stderr, "  [%s error: %v]\n", awk.Name, err)
+				fmt.Fprintf(os.Stderr, "%-20s [verification skipped: %v]\n", label, err)
+			}
+			for name, ferr := range progFailures {
+				fmt.Fprintf(os.Stderr, "%-20s [%s differs from %s: %v]\n", label, name, source, ferr)
+			}
+			failures[key] = progFailures
+		}
+	}
+
+	// Run benchmarks
+	for _, cells := range groups {
+		if ctx.Err() != nil {
+			break
+		}
+		cellResults, errs := r.BenchmarkCells(ctx, cells)
+
+		var prev string
+		for i, c := range cells {
+			if c.Program != prev {
+				if prev != "" {
+					fmt.Println()
+				}
+				fmt.Printf("%-20s ", filepath.Base(c.Program))
+				prev = c.Program
+			}
+
+			name := c.AWK.Name
+			if len(modes) > 1 {
+				name += "/" + string(c.InputMode)
+			}
+			if len(layouts) > 1 {
+				name += "/" + c.Layout
+			}
+			if len(locales) > 1 {
+				name += "/" + c.Locale
+			}
+			result := cellResults[i]
+			if errs[i] != nil {
+				fmt.Printf("%s:ERR ", name)
+				fmt.Fprintf(os.Stderr, "  [%s error: %v]\n", c.AWK.Name, errs[i])
+				results = append(results, *result) // Failed, with its failed runs
 				continue
 			}
+			if ferr, ok := failures[check{c.Program, c.InputMode, c.Layout, c.Locale}][c.AWK.Name]; ok {
+				result.Failed = true
+				result.FailReason = ferr.Error()
+				fmt.Printf("%s:FAILED ", name)
+			} else {
+				fmt.Printf("%s:%.1fms ", name, float64(result.Mean.Milliseconds()))
+			}
+			if n := len(result.Failures); n > 0 {
+				fmt.Printf("(%d failed) ", n)
+			}
+			if profileMode && ctx.Err() == nil {
+				result.Profiles = profileCell(ctx, r, c, kinds)
+			}
 			results = append(results, *result)
-			fmt.Printf("%s:%.1fms ", awk.Name, float64(result.Mean.Milliseconds()))
 		}
 		fmt.Println()
 	}
 
+	if ctx.Err() != nil {
+		fmt.Println("\nInterrupted, writing partial results")
+	}
+
+	// Startup cost, measured like a program without input
+	if (*startup || *net) && ctx.Err() == nil {
+		fmt.Printf("%-20s ", runner.StartupProgram)
+		probes, errs := r.ProbeStartup(ctx, awks, locales)
+		for _, p := range probes {
+			name := p.AWK
+			if len(locales) > 1 && p.AWK != runner.HarnessName {
+				name += "/" + p.Locale
+			}
+			fmt.Printf("%s:%.1fms ", name, float64(p.Median.Microseconds())/1e3)
+		}
+		fmt.Println()
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "  [startup error: %v]\n", err)
+		}
+		if *net {
+			runner.SubtractStartup(results, probes)
+		}
+		results = append(results, probes...)
+	}
+
 	// Write results
 	if err := os.MkdirAll(*outputDir, 0755); err != nil {
 		return fmt.Errorf("creating output dir: %w", err)
@@ -194,7 +521,7 @@ func run() error {
 	}
 	report.WriteSummary(f, results)
 	report.WriteMarkdown(f, results)
-	writeSystemInfo(f)
+	writeSystemInfo(f, r)
 	f.Close()
 	fmt.Printf("\nResults written to %s\n", mdFile)
 
@@ -203,20 +530,256 @@ func run() error {
 	if err != nil {
 		return err
 	}
-	report.WriteJSON(f, results)
-	f.Close()
+	if err := report.WriteJSON(f, results); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s: %w", jsonFile, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", jsonFile, err)
+	}
 
 	// CSV
 	f, err = os.Create(csvFile)
 	if err != nil {
 		return err
 	}
-	report.WriteCSV(f, results)
-	f.Close()
+	if err := report.WriteCSV(f, results); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s: %w", csvFile, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", csvFile, err)
+	}
+
+	// Scaling curves
+	if report.HasScaling(results) {
+		scalingFile := filepath.Join(*outputDir, "scaling.csv")
+		f, err = os.Create(scalingFile)
+		if err != nil {
+			return err
+		}
+		if err := report.WriteScalingCSV(f, results); err != nil {
+			f.Close()
+			return fmt.Errorf("writing %s: %w", scalingFile, err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("writing %s: %w", scalingFile, err)
+		}
+	}
 
 	return nil
 }
 
+// profileCell captures each kind of profile of a cell into
+// <output>/profiles, reporting failures on stderr.
+func profileCell(ctx context.Context, r *runner.Runner, c runner.Cell, kinds []runner.ProfileKind) []runner.Profile {
+	var profiles []runner.Profile
+	for _, kind := range kinds {
+		ext := ".pprof"
+		if !c.AWK.Profiler.Supports(kind) {
+			ext = ".prof" // Wrapper output
+		}
+		var parts []string
+		for _, p := range []string{strings.TrimSuffix(filepath.Base(c.Program), ".awk"), c.AWK.Name, string(c.InputMode), c.Layout, c.Locale, string(kind)} {
+			if p != "" {
+				parts = append(parts, strings.NewReplacer(" ", "_", "/", "_", "=", "-").Replace(p))
+			}
+		}
+		path := filepath.Join(*outputDir, "profiles", strings.Join(parts, ".")+ext)
+
+		p := r.ProfileCell(ctx, c, kind, path)
+		if p.Error != "" {
+			if p.Error != runner.ErrNoProfiler.Error() {
+				fmt.Fprintf(os.Stderr, "  [%s %s profile: %s]\n", c.AWK.Name, kind, p.Error)
+			}
+			continue
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles
+}
+
+// inputLayout is a way of laying out the datasets on disk.
+type inputLayout struct {
+	name  string
+	files map[string][]string // dataset -> input files
+}
+
+// inputLayouts returns the layouts selected by -layouts: the generated
+// files as they are, split into -shards shards, and/or split into
+// files of -small-file-size bytes.
+func inputLayouts(gen *dataset.Generator, files map[string]string) ([]inputLayout, error) {
+	var layouts []inputLayout
+	for _, name := range strings.Split(*layoutList, ",") {
+		switch strings.TrimSpace(name) {
+		case "single":
+			single := make(map[string][]string, len(files))
+			for data, path := range files {
+				single[data] = []string{path}
+			}
+			layouts = append(layouts, inputLayout{"single", single})
+		case "shards":
+			if *shardCount < 2 {
+				return nil, fmt.Errorf("invalid shard count: %d (need at least 2)", *shardCount)
+			}
+			shards, err := gen.ShardAll(files, *shardCount)
+			if err != nil {
+				return nil, fmt.Errorf("sharding data: %w", err)
+			}
+			layouts = append(layouts, inputLayout{fmt.Sprintf("%d shards", *shardCount), shards})
+		case "small":
+			if *smallSize < 1 {
+				return nil, fmt.Errorf("invalid small file size: %d", *smallSize)
+			}
+			small, err := gen.SplitAll(files, int64(*smallSize))
+			if err != nil {
+				return nil, fmt.Errorf("splitting data into small files: %w", err)
+			}
+			layouts = append(layouts, inputLayout{fmt.Sprintf("%dB files", *smallSize), small})
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown layout %q (use single, shards, small)", name)
+		}
+	}
+	if len(layouts) == 0 {
+		return nil, fmt.Errorf("no input layouts given")
+	}
+	return layouts, nil
+}
+
+// totalSize returns the combined size of files.
+func totalSize(files []string) int64 {
+	var size int64
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// withSettings returns a copy of v whose runs use the given locale and
+// input mode, so each cell is verified the way it is timed.
+func withSettings(v *verify.Verifier, locale string, mode runner.InputMode) *verify.Verifier {
+	r := *v.Runner
+	r.Locale = locale
+	r.InputMode = mode
+	lv := *v
+	lv.Runner = &r
+	return &lv
+}
+
+// scalingAWKs returns the variants of the scaling curves: the -awk AWKs
+// (default uawk) at every value of each knob from 1 to -scaling-max.
+func scalingAWKs(allAWKs []runner.AWK) ([]runner.AWK, error) {
+	n := *scalingMax
+	if n == 0 {
+		cpus, err := runner.ParseCPUList(*cpuList)
+		if err == nil && len(cpus) == 0 {
+			cpus, err = runner.AllowedCPUs()
+		}
+		if err != nil {
+			return nil, err
+		}
+		n = len(cpus)
+		if n == 0 {
+			n = runtime.NumCPU()
+		}
+	}
+
+	names := []string{"uawk"}
+	if *awkList != "" {
+		names = strings.Split(*awkList, ",")
+		for i := range names {
+			names[i] = strings.TrimSpace(names[i])
+		}
+	}
+	var variants []runner.AWK
+	for _, knob := range strings.Split(*scaling, ",") {
+		if knob = strings.TrimSpace(knob); knob == "" {
+			continue
+		}
+		spec, err := sweep.Scaling(names, knob, n)
+		if err != nil {
+			return nil, err
+		}
+		expanded, err := spec.Expand(lookupAWK(allAWKs))
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, runner.FindAvailable(expanded)...)
+	}
+	return variants, nil
+}
+
+// lookupAWK resolves names to allAWKs entries or, failing that, to a
+// command of that name.
+func lookupAWK(allAWKs []runner.AWK) func(string) (runner.AWK, bool) {
+	return func(name string) (runner.AWK, bool) {
+		for _, awk := range allAWKs {
+			if awk.Name == name {
+				return awk, true
+			}
+		}
+		return runner.AWK{Name: name, Command: name}, true
+	}
+}
+
+// sweepAWKs expands the sweep specs into the available AWK variants.
+func sweepAWKs(specs string, allAWKs []runner.AWK) ([]runner.AWK, error) {
+	var variants []runner.AWK
+	for _, s := range strings.Split(specs, ";") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		spec, err := sweep.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		expanded, err := spec.Expand(lookupAWK(allAWKs))
+		if err != nil {
+			return nil, err
+		}
+		available := runner.FindAvailable(expanded)
+		if len(available) < len(expanded) {
+			fmt.Printf("Sweep %q: %d of %d variants unavailable, skipped\n", strings.TrimSpace(s), len(expanded)-len(available), len(expanded))
+		}
+		variants = append(variants, available...)
+	}
+	return variants, nil
+}
+
+// newVerifier sets up output verification against golden files for the
+// current size and/or the reference AWK, if it is installed.
+func newVerifier(r *runner.Runner, allAWKs, awks []runner.AWK) *verify.Verifier {
+	v := &verify.Verifier{Runner: r}
+	if *goldenDir != "" {
+		v.GoldenDir = filepath.Join(*goldenDir, *size)
+	}
+
+	// Prefer the reference as configured for this run
+	for i := range awks {
+		if awks[i].Name == *reference {
+			v.Reference = &awks[i]
+			return v
+		}
+	}
+	for _, awk := range allAWKs {
+		if awk.Name == *reference {
+			if found := runner.FindAvailable([]runner.AWK{awk}); len(found) > 0 {
+				v.Reference = &found[0]
+				return v
+			}
+		}
+	}
+
+	if v.GoldenDir == "" {
+		fmt.Fprintf(os.Stderr, "Warning: reference AWK %q not found, outputs will not be verified\n", *reference)
+		return nil
+	}
+	return v
+}
+
 func parseSize(s string) dataset.Size {
 	s = strings.ToUpper(strings.TrimSpace(s))
 	switch s {
@@ -233,10 +796,32 @@ func parseSize(s string) dataset.Size {
 	}
 }
 
-func writeSystemInfo(f *os.File) {
+func writeSystemInfo(f *os.File, r *runner.Runner) {
 	fmt.Fprintf(f, "## System Info\n\n")
 	fmt.Fprintf(f, "- OS: %s\n", runtime.GOOS)
 	fmt.Fprintf(f, "- Arch: %s\n", runtime.GOARCH)
 	fmt.Fprintf(f, "- CPUs: %d\n", runtime.NumCPU())
 	fmt.Fprintf(f, "- Go: %s\n", runtime.Version())
+	if len(r.CPUs) > 0 {
+		fmt.Fprintf(f, "- CPU pinning: %s\n", runner.FormatCPUList(r.CPUs))
+	}
+	if r.Nice != 0 {
+		fmt.Fprintf(f, "- Nice: %d\n", r.Nice)
+	}
+	if r.Counters {
+		fmt.Fprintf(f, "- Performance counters: perf_event_open\n")
+	}
+	if r.SampleInterval > 0 {
+		fmt.Fprintf(f, "- RSS and I/O sampling: every %s\n", r.SampleInterval)
+	}
+	fmt.Fprintf(f, "- Page cache: %s\n", r.CacheMode)
+	if r.InheritEnv {
+		fmt.Fprintf(f, "- Environment: inherited, LC_ALL per cell\n")
+	} else {
+		fmt.Fprintf(f, "- Environment: clean (PATH, HOME, temp dirs), LC_ALL per cell\n")
+	}
+	fmt.Fprintf(f, "- Schedule: %s (scope: %s)\n", r.Schedule, *scope)
+	if r.Schedule == runner.Shuffle {
+		fmt.Fprintf(f, "- Seed: %d\n", r.Seed)
+	}
 }
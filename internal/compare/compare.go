@@ -0,0 +1,216 @@
+// Package compare checks AWK outputs for equivalence using per-program rules.
+//
+// Rules are declared in a program's header comments:
+//
+//	# Verify: sort tolerance=1e-5
+//
+// or in a sidecar file next to the program (groupby.awk -> groupby.verify)
+// containing the same directives, one or more per line.
+package compare
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Rules describe how outputs are canonicalized before comparison.
+// The zero value compares outputs byte for byte.
+type Rules struct {
+	SortLines  bool    // Ignore line order (e.g. "for (k in a)" loops)
+	Fields     bool    // Compare whitespace-separated fields
+	Tolerance  float64 // Relative tolerance for numeric fields (implies Fields)
+	Whitespace bool    // Collapse runs of blanks and trim line ends
+}
+
+// Exact reports whether the rules require byte-for-byte equality.
+func (r Rules) Exact() bool {
+	return r == Rules{}
+}
+
+// String returns the rules in directive syntax.
+func (r Rules) String() string {
+	var parts []string
+	if r.SortLines {
+		parts = append(parts, "sort")
+	}
+	if r.Fields {
+		parts = append(parts, "fields")
+	}
+	if r.Tolerance > 0 {
+		parts = append(parts, "tolerance="+strconv.FormatFloat(r.Tolerance, 'g', -1, 64))
+	}
+	if r.Whitespace {
+		parts = append(parts, "whitespace")
+	}
+	if len(parts) == 0 {
+		return "exact"
+	}
+	return strings.Join(parts, " ")
+}
+
+// Parse adds the directives in s to the rules.
+// Directives: sort, fields, whitespace, tolerance=<relative>, exact.
+func (r *Rules) Parse(s string) error {
+	for _, d := range strings.Fields(s) {
+		key, value, _ := strings.Cut(strings.ToLower(d), "=")
+		switch key {
+		case "exact":
+			*r = Rules{}
+		case "sort":
+			r.SortLines = true
+		case "fields":
+			r.Fields = true
+		case "whitespace":
+			r.Whitespace = true
+		case "tolerance":
+			tol, err := strconv.ParseFloat(value, 64)
+			if err != nil || tol < 0 {
+				return fmt.Errorf("invalid tolerance %q", value)
+			}
+			r.Tolerance = tol
+			r.Fields = true
+		default:
+			return fmt.Errorf("unknown directive %q", d)
+		}
+	}
+	return nil
+}
+
+// Load reads the rules for an AWK program from its header comments and
+// from an optional sidecar file (<program>.verify).
+func Load(programFile string) (Rules, error) {
+	var rules Rules
+
+	f, err := os.Open(programFile)
+	if err != nil {
+		return rules, err
+	}
+	defer f.Close()
+
+	// Only the leading comment block is scanned
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		text := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		if key, value, ok := strings.Cut(text, ":"); ok && strings.EqualFold(key, "verify") {
+			if err := rules.Parse(value); err != nil {
+				return rules, fmt.Errorf("%s: %w", programFile, err)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return rules, err
+	}
+
+	sidecar := strings.TrimSuffix(programFile, ".awk") + ".verify"
+	data, err := os.ReadFile(sidecar)
+	if errors.Is(err, os.ErrNotExist) {
+		return rules, nil
+	}
+	if err != nil {
+		return rules, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		if err := rules.Parse(line); err != nil {
+			return rules, fmt.Errorf("%s: %w", sidecar, err)
+		}
+	}
+	return rules, nil
+}
+
+// Diff compares got against want under the rules.
+// It returns nil if the outputs are equivalent, otherwise an error
+// describing the first difference.
+func (r Rules) Diff(want, got string) error {
+	if want == got {
+		return nil
+	}
+
+	wantLines := r.canonical(want)
+	gotLines := r.canonical(got)
+	for i := 0; i < len(wantLines) && i < len(gotLines); i++ {
+		if !r.equalLines(wantLines[i], gotLines[i]) {
+			return fmt.Errorf("line %d: want %q, got %q", i+1, Clip(wantLines[i]), Clip(gotLines[i]))
+		}
+	}
+	if len(wantLines) != len(gotLines) {
+		return fmt.Errorf("output has %d lines, want %d", len(gotLines), len(wantLines))
+	}
+	return nil
+}
+
+// canonical splits output into lines and applies whitespace and order rules.
+func (r Rules) canonical(s string) []string {
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	if s == "" {
+		lines = nil
+	}
+	if r.Whitespace || r.Fields {
+		for i, line := range lines {
+			lines[i] = strings.Join(strings.Fields(line), " ")
+		}
+	}
+	if r.SortLines {
+		sort.Strings(lines)
+	}
+	return lines
+}
+
+// equalLines compares two canonical lines, field by field if required.
+func (r Rules) equalLines(want, got string) bool {
+	if want == got {
+		return true
+	}
+	if !r.Fields {
+		return false
+	}
+
+	wantFields := strings.Fields(want)
+	gotFields := strings.Fields(got)
+	if len(wantFields) != len(gotFields) {
+		return false
+	}
+	for i := range wantFields {
+		if wantFields[i] != gotFields[i] && !r.equalNumbers(wantFields[i], gotFields[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalNumbers reports whether both fields are numbers within the
+// relative tolerance of each other.
+func (r Rules) equalNumbers(want, got string) bool {
+	a, err := strconv.ParseFloat(want, 64)
+	if err != nil {
+		return false
+	}
+	b, err := strconv.ParseFloat(got, 64)
+	if err != nil {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	scale := math.Max(math.Abs(a), math.Abs(b))
+	return math.Abs(a-b) <= r.Tolerance*scale
+}
+
+// Clip shortens a line for error messages.
+func Clip(s string) string {
+	const max = 60
+	if len(s) > max {
+		return s[:max] + "..."
+	}
+	return s
+}
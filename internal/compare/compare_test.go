@@ -0,0 +1,123 @@
+package compare
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Rules
+		wantErr bool
+	}{
+		{"", Rules{}, false},
+		{"sort", Rules{SortLines: true}, false},
+		{"sort tolerance=1e-6", Rules{SortLines: true, Fields: true, Tolerance: 1e-6}, false},
+		{"Fields WHITESPACE", Rules{Fields: true, Whitespace: true}, false},
+		{"sort exact", Rules{}, false},
+		{"tolerance=-1", Rules{}, true},
+		{"tolerance=x", Rules{}, true},
+		{"shuffle", Rules{}, true},
+	}
+	for _, tt := range tests {
+		var got Rules
+		err := got.Parse(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRulesString(t *testing.T) {
+	tests := []struct {
+		rules Rules
+		want  string
+	}{
+		{Rules{}, "exact"},
+		{Rules{SortLines: true}, "sort"},
+		{Rules{SortLines: true, Fields: true, Tolerance: 1e-6}, "sort fields tolerance=1e-06"},
+		{Rules{Whitespace: true}, "whitespace"},
+	}
+	for _, tt := range tests {
+		if got := tt.rules.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.rules, got, tt.want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     Rules
+		want, got string
+		equal     bool
+	}{
+		{"exact same", Rules{}, "a 1\nb 2\n", "a 1\nb 2\n", true},
+		{"exact order", Rules{}, "a 1\nb 2\n", "b 2\na 1\n", false},
+		{"exact missing newline", Rules{}, "a 1\n", "a 1", true},
+		{"exact extra line", Rules{}, "a 1\n", "a 1\nb 2\n", false},
+		{"sort order", Rules{SortLines: true}, "a 1\nb 2\n", "b 2\na 1\n", true},
+		{"sort content", Rules{SortLines: true}, "a 1\nb 2\n", "b 2\na 3\n", false},
+		{"whitespace", Rules{Whitespace: true}, "a  1\n", "a\t1 \n", true},
+		{"fields", Rules{Fields: true}, "a 1\n", "a   1\n", true},
+		{"fields numeric", Rules{Fields: true}, "a 1\n", "a 1.0\n", true},
+		{"fields differ", Rules{Fields: true}, "a 1\n", "a 1.0000001\n", false},
+		{"tolerance within", Rules{Fields: true, Tolerance: 1e-6}, "sum 3.0000001\n", "sum 3\n", true},
+		{"tolerance beyond", Rules{Fields: true, Tolerance: 1e-6}, "sum 3.001\n", "sum 3\n", false},
+		{"tolerance text", Rules{Fields: true, Tolerance: 1e-6}, "sum x\n", "sum y\n", false},
+		{"tolerance field count", Rules{Fields: true, Tolerance: 1e-6}, "a 1 2\n", "a 1\n", false},
+		{"empty", Rules{SortLines: true}, "", "", true},
+		{"empty vs line", Rules{SortLines: true}, "", "a\n", false},
+	}
+	for _, tt := range tests {
+		err := tt.rules.Diff(tt.want, tt.got)
+		if (err == nil) != tt.equal {
+			t.Errorf("%s: Diff(%q, %q) = %v, want equal %v", tt.name, tt.want, tt.got, err, tt.equal)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		program string
+		sidecar string // Empty = none
+		want    Rules
+		wantErr bool
+	}{
+		{"none", "{ print }\n", "", Rules{}, false},
+		{"header", "# Group by key\n# Verify: sort\n{ n[$1]++ }\n", "", Rules{SortLines: true}, false},
+		{"header only", "{ print }\n# Verify: sort\n", "", Rules{}, false},
+		{"sidecar", "{ print }\n", "sort # for-in order\ntolerance=1e-9\n", Rules{SortLines: true, Fields: true, Tolerance: 1e-9}, false},
+		{"both", "# verify: whitespace\n{ print }\n", "sort\n", Rules{SortLines: true, Whitespace: true}, false},
+		{"bad header", "# Verify: shuffle\n", "", Rules{}, true},
+		{"bad sidecar", "{ print }\n", "tolerance=x\n", Rules{}, true},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		program := filepath.Join(dir, "prog.awk")
+		if err := os.WriteFile(program, []byte(tt.program), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if tt.sidecar != "" {
+			if err := os.WriteFile(filepath.Join(dir, "prog.verify"), []byte(tt.sidecar), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		got, err := Load(program)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Load error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("%s: Load = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package dataset
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Shard splits a dataset file into n shards of about equal size, cut at
+// line boundaries, and returns their paths in order. Shards are written to
+// <dir>/shards<n>/ next to the file (numeric_10MB.txt ->
+// shards4/numeric_10MB.00.txt ...). The concatenation of the shards is the
+// original file, so programs produce the same output on both layouts.
+func (g *Generator) Shard(path string, n int) ([]string, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid shard count %d", n)
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(filepath.Dir(path), fmt.Sprintf("shards%d", n))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(filepath.Base(path), ext)
+
+	// Zero-padded so that shards sort in order
+	width := len(strconv.Itoa(n - 1))
+	if width < 2 {
+		width = 2
+	}
+
+	r := bufio.NewReader(in)
+	shards := make([]string, n)
+	var read int64
+	for i := range shards {
+		shards[i] = filepath.Join(dir, fmt.Sprintf("%s.%0*d%s", base, width, i, ext))
+		limit := info.Size() * int64(i+1) / int64(n) // Cumulative end of shard i
+		if err := writeShard(shards[i], r, &read, limit); err != nil {
+			return nil, err
+		}
+	}
+	return shards, nil
+}
+
+// writeShard copies whole lines from r to a new file until the
+// cumulative count of bytes read reaches limit.
+func writeShard(path string, r *bufio.Reader, read *int64, limit int64) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	partial := false // Inside a line longer than the read buffer
+	for *read < limit || partial {
+		line, err := r.ReadSlice('\n')
+		partial = err == bufio.ErrBufferFull
+		if _, werr := w.Write(line); werr != nil {
+			return werr
+		}
+		*read += int64(len(line))
+		if err == io.EOF {
+			break
+		}
+		if err != nil && !partial {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// ShardAll shards every dataset in files (as returned by GenerateAll)
+// into n files.
+func (g *Generator) ShardAll(files map[string]string, n int) (map[string][]string, error) {
+	shards := make(map[string][]string, len(files))
+	for name, path := range files {
+		paths, err := g.Shard(path, n)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		shards[name] = paths
+	}
+	return shards, nil
+}
+
+// SplitAll splits every dataset in files (as returned by GenerateAll) into
+// files of about size bytes each, at least two. The file count grows with
+// the dataset; the file size stays the same.
+func (g *Generator) SplitAll(files map[string]string, size int64) (map[string][]string, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("invalid file size %d", size)
+	}
+	split := make(map[string][]string, len(files))
+	for name, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		n := max(2, int((info.Size()+size-1)/size))
+		paths, err := g.Shard(path, n)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		split[name] = paths
+	}
+	return split, nil
+}
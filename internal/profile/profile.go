@@ -0,0 +1,266 @@
+// Package profile reads pprof profiles (gzipped profile.proto) far enough
+// to summarize the functions with the most samples.
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Profile is the part of a pprof profile needed for a top-N summary.
+type Profile struct {
+	SampleTypes []ValueType
+	Samples     []Sample
+	locations   map[uint64][]uint64 // Location ID -> function IDs, innermost first
+	functions   map[uint64]string   // Function ID -> name
+}
+
+// ValueType describes one value of every sample, e.g. cpu/nanoseconds.
+type ValueType struct {
+	Type string
+	Unit string
+}
+
+// Sample is a stack (leaf first) with one value per sample type.
+type Sample struct {
+	Locations []uint64
+	Values    []int64
+}
+
+// Entry is the cost of one function.
+type Entry struct {
+	Function string
+	Flat     int64 // In the function itself
+	Cum      int64 // In the function and its callees
+}
+
+// ParseFile reads a profile file.
+func ParseFile(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes a profile, gzipped or not.
+func Parse(data []byte) (*Profile, error) {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = io.ReadAll(zr); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &Profile{
+		locations: make(map[uint64][]uint64),
+		functions: make(map[uint64]string),
+	}
+	var strs []string
+	var types [][2]int64                // String indices of sample types
+	funcNames := make(map[uint64]int64) // Function ID -> string index
+	err := fields(data, func(num int, wire int, v uint64, b []byte) error {
+		switch num {
+		case 1: // sample_type
+			var t [2]int64
+			err := fields(b, func(num, wire int, v uint64, _ []byte) error {
+				if num == 1 || num == 2 {
+					t[num-1] = int64(v)
+				}
+				return nil
+			})
+			types = append(types, t)
+			return err
+		case 2: // sample
+			var s Sample
+			err := fields(b, func(num, wire int, v uint64, b []byte) error {
+				switch num {
+				case 1:
+					return varints(wire, v, b, func(x uint64) { s.Locations = append(s.Locations, x) })
+				case 2:
+					return varints(wire, v, b, func(x uint64) { s.Values = append(s.Values, int64(x)) })
+				}
+				return nil
+			})
+			p.Samples = append(p.Samples, s)
+			return err
+		case 4: // location
+			var id uint64
+			var funcs []uint64
+			err := fields(b, func(num, wire int, v uint64, b []byte) error {
+				switch num {
+				case 1:
+					id = v
+				case 4: // line
+					return fields(b, func(num, wire int, v uint64, _ []byte) error {
+						if num == 1 {
+							funcs = append(funcs, v)
+						}
+						return nil
+					})
+				}
+				return nil
+			})
+			p.locations[id] = funcs
+			return err
+		case 5: // function
+			var id uint64
+			var name int64
+			err := fields(b, func(num, wire int, v uint64, _ []byte) error {
+				switch num {
+				case 1:
+					id = v
+				case 2:
+					name = int64(v)
+				}
+				return nil
+			})
+			funcNames[id] = name
+			return err
+		case 6: // string_table
+			strs = append(strs, string(b))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decoding profile: %w", err)
+	}
+
+	str := func(i int64) string {
+		if i < 0 || i >= int64(len(strs)) {
+			return ""
+		}
+		return strs[i]
+	}
+	for _, t := range types {
+		p.SampleTypes = append(p.SampleTypes, ValueType{Type: str(t[0]), Unit: str(t[1])})
+	}
+	for id, name := range funcNames {
+		p.functions[id] = str(name)
+	}
+	return p, nil
+}
+
+// Total returns the sum of value i over all samples.
+func (p *Profile) Total(i int) int64 {
+	var total int64
+	for _, s := range p.Samples {
+		if i < len(s.Values) {
+			total += s.Values[i]
+		}
+	}
+	return total
+}
+
+// Top returns the n functions with the highest flat value i, highest
+// first. Inlined functions count as functions of their own.
+func (p *Profile) Top(i, n int) []Entry {
+	flat := make(map[string]int64)
+	cum := make(map[string]int64)
+	for _, s := range p.Samples {
+		if i >= len(s.Values) {
+			continue
+		}
+		v := s.Values[i]
+		seen := make(map[string]bool)
+		for depth, loc := range s.Locations {
+			for j, fn := range p.locations[loc] {
+				name := p.functions[fn]
+				if depth == 0 && j == 0 {
+					flat[name] += v
+				}
+				if !seen[name] {
+					seen[name] = true
+					cum[name] += v
+				}
+			}
+		}
+	}
+
+	entries := make([]Entry, 0, len(cum))
+	for name, c := range cum {
+		entries = append(entries, Entry{Function: name, Flat: flat[name], Cum: c})
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		if entries[a].Flat != entries[b].Flat {
+			return entries[a].Flat > entries[b].Flat
+		}
+		return entries[a].Function < entries[b].Function
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// fields calls fn for every field of a protobuf message: v holds varint
+// and fixed values, b length-delimited ones.
+func fields(data []byte, fn func(num, wire int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("bad field key")
+		}
+		data = data[n:]
+		num, wire := int(key>>3), int(key&7)
+
+		var v uint64
+		var b []byte
+		switch wire {
+		case 0: // varint
+			v, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errors.New("bad varint")
+			}
+			data = data[n:]
+		case 1: // fixed64
+			if len(data) < 8 {
+				return io.ErrUnexpectedEOF
+			}
+			v, data = binary.LittleEndian.Uint64(data), data[8:]
+		case 2: // length-delimited
+			l, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < l {
+				return io.ErrUnexpectedEOF
+			}
+			b, data = data[n:n+int(l)], data[n+int(l):]
+		case 5: // fixed32
+			if len(data) < 4 {
+				return io.ErrUnexpectedEOF
+			}
+			v, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		default:
+			return fmt.Errorf("unsupported wire type %d", wire)
+		}
+		if err := fn(num, wire, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// varints calls fn for a repeated varint field, packed or not.
+func varints(wire int, v uint64, b []byte, fn func(uint64)) error {
+	if wire == 0 {
+		fn(v)
+		return nil
+	}
+	for len(b) > 0 {
+		x, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errors.New("bad packed varint")
+		}
+		fn(x)
+		b = b[n:]
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"reflect"
+	"runtime/pprof"
+	"testing"
+)
+
+// msg builds protobuf messages for test profiles.
+type msg []byte
+
+func (m msg) varint(num int, v uint64) msg {
+	m = binary.AppendUvarint(m, uint64(num)<<3)
+	return binary.AppendUvarint(m, v)
+}
+
+func (m msg) bytes(num int, b []byte) msg {
+	m = binary.AppendUvarint(m, uint64(num)<<3|2)
+	m = binary.AppendUvarint(m, uint64(len(b)))
+	return append(m, b...)
+}
+
+func (m msg) packed(num int, vs ...uint64) msg {
+	var b []byte
+	for _, v := range vs {
+		b = binary.AppendUvarint(b, v)
+	}
+	return m.bytes(num, b)
+}
+
+// testProfile encodes a profile with samples/count and cpu/nanoseconds
+// values:
+//
+//	main -> parse -> lex -> inlined (inlined into lex)  1, 10
+//	main -> parse -> parse                              2, 20
+//	main                                                3, 30
+func testProfile() []byte {
+	var p msg
+	for _, s := range []string{"", "cpu", "nanoseconds", "samples", "count", "main", "parse", "lex", "inlined"} {
+		p = p.bytes(6, []byte(s))
+	}
+	p = p.bytes(1, msg{}.varint(1, 3).varint(2, 4))
+	p = p.bytes(1, msg{}.varint(1, 1).varint(2, 2))
+	for id, name := range map[uint64]uint64{1: 5, 2: 6, 3: 7, 4: 8} {
+		p = p.bytes(5, msg{}.varint(1, id).varint(2, name))
+	}
+	p = p.bytes(4, msg{}.varint(1, 1).bytes(4, msg{}.varint(1, 1)))
+	p = p.bytes(4, msg{}.varint(1, 2).bytes(4, msg{}.varint(1, 2)))
+	p = p.bytes(4, msg{}.varint(1, 3).bytes(4, msg{}.varint(1, 4)).bytes(4, msg{}.varint(1, 3)))
+	p = p.bytes(2, msg{}.packed(1, 3, 2, 1).packed(2, 1, 10))
+	p = p.bytes(2, msg{}.packed(1, 2, 2, 1).packed(2, 2, 20))
+	p = p.bytes(2, msg{}.varint(1, 1).varint(2, 3).varint(2, 30)) // Unpacked
+
+	// Fields the decoder skips: fixed64 and fixed32
+	p = binary.AppendUvarint(p, 20<<3|1)
+	p = binary.LittleEndian.AppendUint64(p, 1)
+	p = binary.AppendUvarint(p, 21<<3|5)
+	p = binary.LittleEndian.AppendUint32(p, 1)
+	return p
+}
+
+func gzipped(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	data := testProfile()
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"plain", data},
+		{"gzipped", gzipped(data)},
+	}
+	for _, tt := range tests {
+		p, err := Parse(tt.data)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		wantTypes := []ValueType{{"samples", "count"}, {"cpu", "nanoseconds"}}
+		if !reflect.DeepEqual(p.SampleTypes, wantTypes) {
+			t.Errorf("%s: SampleTypes = %v, want %v", tt.name, p.SampleTypes, wantTypes)
+		}
+		wantSamples := []Sample{
+			{Locations: []uint64{3, 2, 1}, Values: []int64{1, 10}},
+			{Locations: []uint64{2, 2, 1}, Values: []int64{2, 20}},
+			{Locations: []uint64{1}, Values: []int64{3, 30}},
+		}
+		if !reflect.DeepEqual(p.Samples, wantSamples) {
+			t.Errorf("%s: Samples = %v, want %v", tt.name, p.Samples, wantSamples)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	data := testProfile()
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated", data[:len(data)-1]},
+		{"bad sample", msg{}.bytes(2, []byte{1<<3 | 2, 5, 1})},
+		{"bad wire type", append(msg{}.varint(6, 0), 3<<3|3)},
+		{"bad gzip", []byte{0x1f, 0x8b, 0}},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.data); err == nil {
+			t.Errorf("%s: Parse succeeded, want error", tt.name)
+		}
+	}
+}
+
+func TestTotal(t *testing.T) {
+	p, err := Parse(testProfile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		i    int
+		want int64
+	}{
+		{0, 6},
+		{1, 60},
+		{2, 0}, // No such value
+	}
+	for _, tt := range tests {
+		if got := p.Total(tt.i); got != tt.want {
+			t.Errorf("Total(%d) = %d, want %d", tt.i, got, tt.want)
+		}
+	}
+}
+
+func TestTop(t *testing.T) {
+	p, err := Parse(testProfile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		i, n int
+		want []Entry
+	}{
+		{"all", 1, 10, []Entry{
+			{"main", 30, 60},
+			{"parse", 20, 30}, // Recursion counts once
+			{"inlined", 10, 10},
+			{"lex", 0, 10}, // Caller of the inlined function
+		}},
+		{"top 2", 1, 2, []Entry{{"main", 30, 60}, {"parse", 20, 30}}},
+		{"counts", 0, 10, []Entry{
+			{"main", 3, 6},
+			{"parse", 2, 3},
+			{"inlined", 1, 1},
+			{"lex", 0, 1},
+		}},
+		{"no such value", 2, 10, []Entry{}},
+	}
+	for _, tt := range tests {
+		if got := p.Top(tt.i, tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Top(%d, %d) = %v, want %v", tt.name, tt.i, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestParseRuntimeProfile(t *testing.T) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Parse(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ValueType{
+		{"alloc_objects", "count"},
+		{"alloc_space", "bytes"},
+		{"inuse_objects", "count"},
+		{"inuse_space", "bytes"},
+	}
+	if !reflect.DeepEqual(p.SampleTypes, want) {
+		t.Errorf("SampleTypes = %v, want %v", p.SampleTypes, want)
+	}
+}
@@ -0,0 +1,91 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/kolkov/uawk-bench/internal/runner"
+)
+
+// writeCounters writes the performance counters of every cell that has
+// them, per program, fastest first.
+func writeCounters(w io.Writer, results []runner.BenchmarkResult) {
+	byProgram, programs := groupByProgram(results, func(r runner.BenchmarkResult) bool { return r.Counters != nil })
+	if len(programs) == 0 {
+		return
+	}
+	userOnly := false
+	for _, cells := range byProgram {
+		for _, r := range cells {
+			userOnly = userOnly || r.Counters.UserOnly
+		}
+	}
+
+	fmt.Fprintf(w, "## Performance Counters (median)\n\n")
+	for _, prog := range programs {
+		cells := byProgram[prog]
+		sort.Slice(cells, func(i, j int) bool { return cells[i].Median < cells[j].Median })
+
+		fmt.Fprintf(w, "### %s\n\n", prog)
+		fmt.Fprintf(w, "| AWK | Median | IPC | Instr/Byte | Instructions | Cycles | Branch Miss/KI | Cache Miss/KI | Task Clock |\n")
+		fmt.Fprintf(w, "|-----|--------|-----|------------|--------------|--------|----------------|---------------|------------|\n")
+		for _, r := range cells {
+			c := r.Counters
+			fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s | %s | %s | %s |\n",
+				r.AWK, formatDuration(r.Median),
+				formatRatio(c.IPC, "%.2f"), formatRatio(c.InstructionsPerByte, "%.1f"),
+				formatCount(c.Instructions), formatCount(c.Cycles),
+				formatPerKI(c.BranchMissesPerKI, c.BranchMisses), formatPerKI(c.CacheMissesPerKI, c.CacheMisses),
+				formatTaskClock(c.TaskClock))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	fmt.Fprintf(w, "Counts include every thread and child process of the AWK. Instr/Byte divides by the input size; Miss/KI is per 1000 instructions.")
+	if userOnly {
+		fmt.Fprintf(w, " Kernel-mode counts are excluded (perf_event_paranoid).")
+	}
+	fmt.Fprintf(w, "\n\n")
+}
+
+// formatRatio formats a derived ratio, or "-" if it is not available.
+func formatRatio(v float64, format string) string {
+	if v <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf(format, v)
+}
+
+// formatPerKI formats events per 1000 instructions, or "-" if either
+// counter is not available.
+func formatPerKI(v float64, events runner.Summary) string {
+	if v <= 0 && events.Median <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+// formatCount formats a counter median with an SI suffix.
+func formatCount(s runner.Summary) string {
+	n := float64(s.Median)
+	switch {
+	case s.Median <= 0:
+		return "-"
+	case n >= 1e9:
+		return fmt.Sprintf("%.2fG", n/1e9)
+	case n >= 1e6:
+		return fmt.Sprintf("%.2fM", n/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%.1fK", n/1e3)
+	}
+	return fmt.Sprintf("%d", s.Median)
+}
+
+// formatTaskClock formats the median task clock, or "-".
+func formatTaskClock(s runner.Summary) string {
+	if s.Median <= 0 {
+		return "-"
+	}
+	return formatDuration(time.Duration(s.Median))
+}
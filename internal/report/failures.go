@@ -0,0 +1,136 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/kolkov/uawk-bench/internal/runner"
+)
+
+// writeFailures writes a matrix of failed runs (program by AWK) followed
+// by a trimmed stderr excerpt of every distinct failure.
+func writeFailures(w io.Writer, results []runner.BenchmarkResult) {
+	var failing []runner.BenchmarkResult
+	for _, r := range results {
+		if len(r.Failures) > 0 {
+			failing = append(failing, r)
+		}
+	}
+	if len(failing) == 0 {
+		return
+	}
+
+	awks := distinct(results, func(r runner.BenchmarkResult) string { return r.AWK })
+	cells := make(map[string]map[string]runner.BenchmarkResult)
+	var rows []string
+	for _, r := range results {
+		row := r.Program + variant(r)
+		if cells[row] == nil {
+			cells[row] = make(map[string]runner.BenchmarkResult)
+			rows = append(rows, row)
+		}
+		cells[row][r.AWK] = r
+	}
+	sort.Strings(rows)
+
+	fmt.Fprintf(w, "## Failed Runs\n\n")
+	fmt.Fprintf(w, "| Program | %s |\n", strings.Join(awks, " | "))
+	fmt.Fprintf(w, "|---------|%s\n", strings.Repeat("------|", len(awks)))
+	for _, row := range rows {
+		fmt.Fprintf(w, "| %s |", row)
+		for _, awk := range awks {
+			r, ok := cells[row][awk]
+			if !ok {
+				fmt.Fprintf(w, " - |")
+				continue
+			}
+			fmt.Fprintf(w, " %s |", failureCell(r))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	fmt.Fprintf(w, "\n")
+
+	for _, r := range failing {
+		seen := make(map[string]bool)
+		for _, s := range r.Failures {
+			key := s.Status.String() + "\x00" + s.Stderr
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			fmt.Fprintf(w, "- **%s** %s%s: %s", r.AWK, r.Program, variant(r), s.Status)
+			if s.Stderr != "" {
+				fmt.Fprintf(w, " `%s`", oneLine(s.Stderr))
+			}
+			fmt.Fprintf(w, "\n")
+		}
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+// failureCell summarizes the failed runs of a cell, e.g. "2× timeout".
+// Cells that were aborted are marked as such.
+func failureCell(r runner.BenchmarkResult) string {
+	if len(r.Failures) == 0 {
+		return "ok"
+	}
+
+	counts := make(map[string]int)
+	var order []string
+	for _, s := range r.Failures {
+		status := s.Status.String()
+		if counts[status] == 0 {
+			order = append(order, status)
+		}
+		counts[status]++
+	}
+	parts := make([]string, len(order))
+	for i, status := range order {
+		parts[i] = fmt.Sprintf("%d× %s", counts[status], status)
+	}
+
+	s := strings.Join(parts, ", ")
+	if r.Failed {
+		s = "**aborted** " + s
+	}
+	return s
+}
+
+// oneLine flattens a stderr excerpt for an inline code span.
+func oneLine(s string) string {
+	s = strings.ReplaceAll(s, "`", "'")
+	return strings.ReplaceAll(s, "\n", " ⏎ ")
+}
+
+// writeLeftovers lists cells whose AWK left processes running after it
+// exited (system(), pipes to commands). They were killed by the harness.
+func writeLeftovers(w io.Writer, results []runner.BenchmarkResult) {
+	header := false
+	for _, r := range results {
+		var runs int
+		var example []string
+		for _, samples := range [][]runner.Sample{r.Warmups, r.Samples, r.Failures} {
+			for _, s := range samples {
+				if len(s.Leftover) > 0 {
+					runs++
+					example = s.Leftover
+				}
+			}
+		}
+		if runs == 0 {
+			continue
+		}
+
+		if !header {
+			fmt.Fprintf(w, "## Leftover Processes\n\n")
+			header = true
+		}
+		fmt.Fprintf(w, "- **%s** %s%s: %d runs left processes running (e.g. %s)\n",
+			r.AWK, r.Program, variant(r), runs, strings.Join(example, ", "))
+	}
+	if header {
+		fmt.Fprintf(w, "\nRuns are timed to the AWK's own exit. Its output stayed open for up to the grace period while these processes held it; then they were killed.\n\n")
+	}
+}
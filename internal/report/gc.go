@@ -0,0 +1,40 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/kolkov/uawk-bench/internal/runner"
+)
+
+// writeGC writes the GC activity of every traced cell next to its wall
+// time, per program.
+func writeGC(w io.Writer, results []runner.BenchmarkResult) {
+	byProgram, programs := groupByProgram(results, func(r runner.BenchmarkResult) bool { return r.GC != nil })
+	if len(programs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "## Go GC (gctrace, median)\n\n")
+	for _, prog := range programs {
+		cells := byProgram[prog]
+		sort.Slice(cells, func(i, j int) bool { return cells[i].Median < cells[j].Median })
+
+		fmt.Fprintf(w, "### %s\n\n", prog)
+		fmt.Fprintf(w, "| AWK | Median | GCs | STW Pause | Pause / Wall | Heap Goal | GC CPU |\n")
+		fmt.Fprintf(w, "|-----|--------|-----|-----------|--------------|-----------|--------|\n")
+		for _, r := range cells {
+			gc := r.GC
+			share := 0.0
+			if r.Median > 0 {
+				share = float64(gc.Pause) / float64(r.Median)
+			}
+			fmt.Fprintf(w, "| %s | %s | %.0f | %s | %.2f%% | %s | %.0f%% |\n",
+				r.AWK, formatDuration(r.Median), gc.Count, formatDuration(gc.Pause),
+				100*share, formatBytes(gc.HeapGoal), 100*gc.CPUFraction)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	fmt.Fprintf(w, "STW pause sums sweep and mark termination of every cycle; GC CPU is the runtime's estimate of the CPU share spent in GC.\n\n")
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/kolkov/uawk-bench/internal/runner"
+)
+
+// sparkWidth is the number of characters in a sparkline.
+const sparkWidth = 24
+
+// writeMemory writes the sampled RSS of every cell that has it, per
+// program, with a sparkline of the median run.
+func writeMemory(w io.Writer, results []runner.BenchmarkResult) {
+	byProgram, programs := groupByProgram(results, func(r runner.BenchmarkResult) bool { return r.Memory != nil })
+	if len(programs) == 0 {
+		return
+	}
+	interval := formatDuration(byProgram[programs[0]][0].Memory.Interval)
+
+	fmt.Fprintf(w, "## Memory Over Time (sampled every %s)\n\n", interval)
+	for _, prog := range programs {
+		cells := byProgram[prog]
+		sort.Slice(cells, func(i, j int) bool { return cells[i].Memory.PeakRSS < cells[j].Memory.PeakRSS })
+
+		fmt.Fprintf(w, "### %s\n\n", prog)
+		fmt.Fprintf(w, "| AWK | Peak RSS | Avg RSS | RSS | Samples | Storage Read | Read Calls |\n")
+		fmt.Fprintf(w, "|-----|----------|---------|-----|---------|--------------|------------|\n")
+		for _, r := range cells {
+			m := r.Memory
+			rss := make([]float64, len(m.Series))
+			for i, p := range m.Series {
+				rss[i] = float64(p.RSS)
+			}
+			last := m.Series[len(m.Series)-1]
+			fmt.Fprintf(w, "| %s | %s | %s | `%s` | %d | %s | %d |\n",
+				r.AWK, formatBytes(m.PeakRSS), formatBytes(m.AvgRSS),
+				sparkline(rss, sparkWidth), len(m.Series),
+				formatBytes(last.ReadBytes), last.ReadCalls)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	fmt.Fprintf(w, "Peak and average are medians over runs of the sampled RSS of the AWK process (not its children); ")
+	fmt.Fprintf(w, "the sparkline shows the run with the median time, from 0 to its peak. Runs shorter than the interval have no samples.\n\n")
+}
+
+// sparkBars are the levels of a sparkline, lowest first.
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws values as width bars from 0 to their maximum. Values
+// are averaged into buckets when there are more than width of them.
+func sparkline(values []float64, width int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	n := min(width, len(values))
+	buckets := make([]float64, n)
+	var top float64
+	for i := range buckets {
+		lo, hi := i*len(values)/n, (i+1)*len(values)/n
+		var sum float64
+		for _, v := range values[lo:hi] {
+			sum += v
+		}
+		buckets[i] = sum / float64(hi-lo)
+		top = max(top, buckets[i])
+	}
+
+	line := make([]rune, n)
+	for i, v := range buckets {
+		level := 0
+		if top > 0 {
+			level = int(v/top*float64(len(sparkBars)-1) + 0.5)
+		}
+		line[i] = sparkBars[max(0, min(level, len(sparkBars)-1))]
+	}
+	return string(line)
+}
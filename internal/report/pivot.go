@@ -0,0 +1,128 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/kolkov/uawk-bench/internal/runner"
+)
+
+// dimension is a benchmark setting that can vary between cells of the
+// same AWK and program (e.g. input mode).
+type dimension struct {
+	title    string
+	value    func(runner.BenchmarkResult) string
+	implicit string // Value not shown in headings
+}
+
+// dimensions lists the settings results are grouped and pivoted by.
+var dimensions = []dimension{
+	{"Input mode", func(r runner.BenchmarkResult) string { return r.InputMode }, string(runner.InputFile)},
+	{"Layout", func(r runner.BenchmarkResult) string { return r.Layout }, "single"},
+	{"Locale", func(r runner.BenchmarkResult) string { return r.Locale }, "C"},
+}
+
+// variant labels the non-default settings of a result, e.g. "[stdin]".
+func variant(r runner.BenchmarkResult) string {
+	var parts []string
+	for _, d := range dimensions {
+		if v := d.value(r); v != "" && v != d.implicit {
+			parts = append(parts, v)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(parts, ", ") + "]"
+}
+
+// variantWithout labels the settings of a result other than skip.
+func variantWithout(r runner.BenchmarkResult, skip string) string {
+	var parts []string
+	for _, d := range dimensions {
+		if d.title == skip {
+			continue
+		}
+		if v := d.value(r); v != "" && v != d.implicit {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writePivots writes a side-by-side comparison for every dimension that
+// takes more than one value: one row per AWK, one column per value.
+func writePivots(w io.Writer, results []runner.BenchmarkResult) {
+	for _, d := range dimensions {
+		values := distinct(results, d.value)
+		if len(values) < 2 {
+			continue
+		}
+
+		fmt.Fprintf(w, "## %s Comparison (median)\n\n", d.title)
+
+		byProgram := make(map[string][]runner.BenchmarkResult)
+		for _, r := range results {
+			byProgram[r.Program] = append(byProgram[r.Program], r)
+		}
+		programs := make([]string, 0, len(byProgram))
+		for p := range byProgram {
+			programs = append(programs, p)
+		}
+		sort.Strings(programs)
+
+		for _, prog := range programs {
+			// Rows are AWKs (plus any other varying settings)
+			cells := make(map[string]map[string]runner.BenchmarkResult)
+			var rows []string
+			for _, r := range byProgram[prog] {
+				row := r.AWK
+				if other := variantWithout(r, d.title); other != "" {
+					row += " [" + other + "]"
+				}
+				if cells[row] == nil {
+					cells[row] = make(map[string]runner.BenchmarkResult)
+					rows = append(rows, row)
+				}
+				cells[row][d.value(r)] = r
+			}
+			sort.Strings(rows)
+
+			fmt.Fprintf(w, "### %s\n\n", prog)
+			fmt.Fprintf(w, "| AWK | %s |\n", strings.Join(values, " | "))
+			fmt.Fprintf(w, "|-----|%s\n", strings.Repeat("------|", len(values)))
+			for _, row := range rows {
+				fmt.Fprintf(w, "| %s |", row)
+				for _, v := range values {
+					r, ok := cells[row][v]
+					switch {
+					case !ok:
+						fmt.Fprintf(w, " - |")
+					case r.Failed:
+						fmt.Fprintf(w, " FAILED |")
+					default:
+						fmt.Fprintf(w, " %s |", formatDuration(r.Median))
+					}
+				}
+				fmt.Fprintf(w, "\n")
+			}
+			fmt.Fprintf(w, "\n")
+		}
+	}
+}
+
+// distinct returns the values of a dimension in first-seen order.
+func distinct(results []runner.BenchmarkResult, value func(runner.BenchmarkResult) string) []string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, r := range results {
+		v := value(r)
+		if !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,66 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/kolkov/uawk-bench/internal/runner"
+)
+
+// writeProfiles writes the top functions of every profile captured by
+// awkbench profile.
+func writeProfiles(w io.Writer, results []runner.BenchmarkResult) {
+	var profiled []runner.BenchmarkResult
+	for _, r := range results {
+		if len(r.Profiles) > 0 {
+			profiled = append(profiled, r)
+		}
+	}
+	if len(profiled) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "## Profiles\n\n")
+	for _, r := range profiled {
+		for _, p := range r.Profiles {
+			fmt.Fprintf(w, "### %s%s: %s (%s)\n\n", r.Program, variant(r), r.AWK, p.Kind)
+			fmt.Fprintf(w, "`%s`", filepath.ToSlash(p.Path))
+			if len(p.Top) == 0 {
+				fmt.Fprintf(w, " (not summarized)\n\n")
+				continue
+			}
+			fmt.Fprintf(w, ", %s: %s total\n\n", p.Type, formatProfileValue(p.Total, p.Unit))
+			fmt.Fprintf(w, "| Function | Flat | Flat%% | Cum | Cum%% |\n")
+			fmt.Fprintf(w, "|----------|------|-------|-----|------|\n")
+			for _, e := range p.Top {
+				fmt.Fprintf(w, "| `%s` | %s | %.1f%% | %s | %.1f%% |\n",
+					e.Function,
+					formatProfileValue(e.Flat, p.Unit), share(e.Flat, p.Total),
+					formatProfileValue(e.Cum, p.Unit), share(e.Cum, p.Total))
+			}
+			fmt.Fprintf(w, "\n")
+		}
+	}
+	fmt.Fprintf(w, "Profiles come from one extra untimed run per cell; open them with `go tool pprof`.\n\n")
+}
+
+// formatProfileValue formats a profile value in its unit.
+func formatProfileValue(v int64, unit string) string {
+	switch unit {
+	case "nanoseconds":
+		return formatDuration(time.Duration(v))
+	case "bytes":
+		return formatBytes(v)
+	}
+	return fmt.Sprintf("%d", v)
+}
+
+// share returns v as a percentage of total.
+func share(v, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(v) / float64(total)
+}
@@ -2,15 +2,18 @@
 package report
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math"
+	"math/rand"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/kolkov/uawk-bench/internal/runner"
+	"github.com/kolkov/uawk-bench/internal/stats"
 )
 
 // Report holds all benchmark results.
@@ -22,9 +25,9 @@ type Report struct {
 
 // SystemInfo describes the benchmark environment.
 type SystemInfo struct {
-	OS       string
-	Arch     string
-	CPUs     int
+	OS        string
+	Arch      string
+	CPUs      int
 	GoVersion string
 }
 
@@ -33,11 +36,13 @@ func WriteMarkdown(w io.Writer, results []runner.BenchmarkResult) error {
 	if len(results) == 0 {
 		return nil
 	}
+	startup, results := splitStartup(results)
 
-	// Group by program
+	// Group by program and settings (input mode, ...)
 	byProgram := make(map[string][]runner.BenchmarkResult)
 	for _, r := range results {
-		byProgram[r.Program] = append(byProgram[r.Program], r)
+		key := r.Program + variant(r)
+		byProgram[key] = append(byProgram[key], r)
 	}
 
 	// Get sorted program names
@@ -51,44 +56,102 @@ func WriteMarkdown(w io.Writer, results []runner.BenchmarkResult) error {
 	fmt.Fprintf(w, "Generated: %s\n\n", time.Now().Format(time.RFC3339))
 
 	for _, prog := range programs {
-		progResults := byProgram[prog]
+		progResults, failed := splitFailed(byProgram[prog])
 
-		// Sort by mean time (fastest first)
+		// Sort by median time (fastest first)
 		sort.Slice(progResults, func(i, j int) bool {
-			return progResults[i].Mean < progResults[j].Mean
+			return progResults[i].Median < progResults[j].Median
 		})
 
+		// Net time column when startup was subtracted
+		net := len(progResults) > 0 && progResults[0].Startup > 0
+
 		fmt.Fprintf(w, "## %s\n\n", prog)
-		fmt.Fprintf(w, "| AWK | Mean | Min | Max | StdDev | Throughput |\n")
-		fmt.Fprintf(w, "|-----|------|-----|-----|--------|------------|\n")
-
-		baseline := progResults[0].Mean
-		for _, r := range progResults {
-			speedup := ""
-			if r.Mean != baseline {
-				ratio := float64(r.Mean) / float64(baseline)
-				speedup = fmt.Sprintf(" (%.2fx)", ratio)
+		fmt.Fprintf(w, "| AWK | Median [95%% CI] |")
+		if net {
+			fmt.Fprintf(w, " Net (− startup) |")
+		}
+		fmt.Fprintf(w, " vs Fastest | Mean | Min | Max | StdDev | Runs | Outliers | Throughput | User | Sys | Peak RSS | Faults (min/maj) | Ctx Sw (vol/inv) |\n")
+		fmt.Fprintf(w, "|-----|-----------------|")
+		if net {
+			fmt.Fprintf(w, "-----------------|")
+		}
+		fmt.Fprintf(w, "------------|------|-----|-----|--------|------|----------|------------|------|-----|----------|------------------|------------------|\n")
+
+		for i, r := range progResults {
+			vs := "fastest"
+			if i > 0 {
+				vs = compareToFastest(progResults[0], r)
 			}
 
-			fmt.Fprintf(w, "| %s | %s%s | %s | %s | %s | %.1f MB/s |\n",
+			u := r.Usage
+			fmt.Fprintf(w, "| %s | %s [%s, %s] |",
 				r.AWK,
-				formatDuration(r.Mean), speedup,
+				formatDuration(r.Median), formatDuration(r.MedianLow), formatDuration(r.MedianHigh))
+			if net {
+				fmt.Fprintf(w, " %s |", formatUncertain(r.Net, float64(r.NetError)))
+			}
+			fmt.Fprintf(w, " %s | %s | %s | %s | %s | %s | %s | %.1f MB/s | %s | %s | %s | %d/%d | %d/%d |\n",
+				vs,
+				formatDuration(r.Mean),
 				formatDuration(r.Min),
 				formatDuration(r.Max),
 				formatDuration(r.StdDev),
+				formatRuns(r),
+				formatOutliers(r),
 				r.Throughput,
+				formatDuration(time.Duration(u.UserTime.Median)),
+				formatDuration(time.Duration(u.SysTime.Median)),
+				formatBytes(u.MaxRSS.Max),
+				u.MinorFaults.Median, u.MajorFaults.Median,
+				u.VolCtxSwitches.Median, u.InvolCtxSwitches.Median,
 			)
 		}
+		for _, r := range failed {
+			fmt.Fprintf(w, "| %s | FAILED |", r.AWK)
+			if net {
+				fmt.Fprintf(w, " - |")
+			}
+			fmt.Fprintf(w, " - | - | - | - | - | - | - | - | - | - | - | - | - |\n")
+		}
 		fmt.Fprintf(w, "\n")
+
+		if len(progResults) > 0 && progResults[0].CacheMode != "" && progResults[0].CacheMode != string(runner.CacheDefault) {
+			fmt.Fprintf(w, "Page cache: %s (input residency before runs: %s).\n\n", progResults[0].CacheMode, formatResidency(progResults))
+		}
+		if len(progResults) > 0 && progResults[0].Robust {
+			fmt.Fprintf(w, "Statistics exclude outliers; raw samples are kept in the JSON report.\n\n")
+		}
+		if net {
+			fmt.Fprintf(w, "Net time subtracts each AWK's startup median (see Startup); ± combines both 95%% CIs.\n\n")
+		}
+		for _, r := range failed {
+			fmt.Fprintf(w, "- **%s**: %s\n", r.AWK, r.FailReason)
+		}
+		if len(failed) > 0 {
+			fmt.Fprintf(w, "\n")
+		}
 	}
 
+	writePivots(w, results)
+	writeSweep(w, results)
+	writeScaling(w, results)
+	writeCounters(w, results)
+	writeMemory(w, results)
+	writeReadRate(w, results)
+	writeGC(w, results)
+	writeProfiles(w, results)
+	writeFailures(w, results)
+	writeLeftovers(w, results)
+	writeStartup(w, startup)
+
 	return nil
 }
 
 // WriteJSON writes results as JSON.
 func WriteJSON(w io.Writer, results []runner.BenchmarkResult) error {
 	report := struct {
-		Generated string                    `json:"generated"`
+		Generated string                   `json:"generated"`
 		Results   []runner.BenchmarkResult `json:"results"`
 	}{
 		Generated: time.Now().Format(time.RFC3339),
@@ -100,22 +163,88 @@ func WriteJSON(w io.Writer, results []runner.BenchmarkResult) error {
 	return enc.Encode(report)
 }
 
-// WriteCSV writes results as CSV.
+// csvColumns are the CSV columns after awk, program, the run summary and
+// the usage metrics.
+var csvColumns = strings.Split("precision,median_ns,median_ci_low_ns,median_ci_high_ns,outliers,trimmed_mean_ns,iqr_ns,cpus,nice,cache_mode,residency,input_mode,layout,startup_ns,net_ns,net_err_ns,failed_runs,locale,params,cycles,instructions,branch_misses,cache_misses,task_clock_ns,ipc,instructions_per_byte,sampled_peak_rss_bytes,sampled_avg_rss_bytes,read_first_quarter_mbps,read_last_quarter_mbps,read_drop,gc_count,gc_pause_ns,gc_heap_goal_bytes,gc_cpu_fraction", ",")
+
+// WriteCSV writes results as CSV (RFC 4180).
 func WriteCSV(w io.Writer, results []runner.BenchmarkResult) error {
-	fmt.Fprintf(w, "awk,program,runs,mean_ns,min_ns,max_ns,stddev_ns,throughput_mbps\n")
+	cw := csv.NewWriter(w)
+	header := []string{"awk", "program", "runs", "mean_ns", "min_ns", "max_ns", "stddev_ns", "throughput_mbps", "status"}
+	for _, m := range usageMetrics {
+		header = append(header, m.name+"_mean", m.name+"_median", m.name+"_max")
+	}
+	cw.Write(append(header, csvColumns...))
+
 	for _, r := range results {
-		fmt.Fprintf(w, "%s,%s,%d,%d,%d,%d,%d,%.2f\n",
+		status := "ok"
+		if r.Failed {
+			status = "FAILED"
+		}
+		row := []string{
 			r.AWK,
 			r.Program,
-			r.Runs,
-			r.Mean.Nanoseconds(),
-			r.Min.Nanoseconds(),
-			r.Max.Nanoseconds(),
-			r.StdDev.Nanoseconds(),
-			r.Throughput,
-		)
+			fmt.Sprint(r.Runs),
+			fmt.Sprint(r.Mean.Nanoseconds()),
+			fmt.Sprint(r.Min.Nanoseconds()),
+			fmt.Sprint(r.Max.Nanoseconds()),
+			fmt.Sprint(r.StdDev.Nanoseconds()),
+			fmt.Sprintf("%.2f", r.Throughput),
+			status,
+		}
+		for _, m := range usageMetrics {
+			s := m.get(r.Usage)
+			row = append(row, fmt.Sprint(s.Mean), fmt.Sprint(s.Median), fmt.Sprint(s.Max))
+		}
+		row = append(row,
+			fmt.Sprintf("%.4f", r.Precision), fmt.Sprint(r.Median.Nanoseconds()),
+			fmt.Sprint(r.MedianLow.Nanoseconds()), fmt.Sprint(r.MedianHigh.Nanoseconds()),
+			fmt.Sprint(len(r.Outliers)), fmt.Sprint(r.TrimmedMean.Nanoseconds()), fmt.Sprint(r.IQR.Nanoseconds()),
+			runner.FormatCPUList(r.CPUs), fmt.Sprint(r.Nice),
+			r.CacheMode, fmt.Sprintf("%.4f", r.Residency), r.InputMode, r.Layout,
+			fmt.Sprint(r.Startup.Nanoseconds()), fmt.Sprint(r.Net.Nanoseconds()), fmt.Sprint(r.NetError.Nanoseconds()),
+			fmt.Sprint(len(r.Failures)), r.Locale, formatParams(r.Params))
+		if c := r.Counters; c != nil {
+			row = append(row,
+				fmt.Sprint(c.Cycles.Median), fmt.Sprint(c.Instructions.Median),
+				fmt.Sprint(c.BranchMisses.Median), fmt.Sprint(c.CacheMisses.Median),
+				fmt.Sprint(c.TaskClock.Median), fmt.Sprintf("%.4f", c.IPC), fmt.Sprintf("%.4f", c.InstructionsPerByte))
+		} else {
+			row = append(row, "", "", "", "", "", "", "")
+		}
+		if m := r.Memory; m != nil {
+			row = append(row, fmt.Sprint(m.PeakRSS), fmt.Sprint(m.AvgRSS))
+		} else {
+			row = append(row, "", "")
+		}
+		if rr := r.ReadRate; rr != nil {
+			row = append(row, fmt.Sprintf("%.2f", rr.FirstQuarter), fmt.Sprintf("%.2f", rr.LastQuarter), fmt.Sprintf("%.4f", rr.Drop))
+		} else {
+			row = append(row, "", "", "")
+		}
+		if gc := r.GC; gc != nil {
+			row = append(row, fmt.Sprintf("%.0f", gc.Count), fmt.Sprint(gc.Pause.Nanoseconds()), fmt.Sprint(gc.HeapGoal), fmt.Sprintf("%.4f", gc.CPUFraction))
+		} else {
+			row = append(row, "", "", "", "")
+		}
+		cw.Write(row)
 	}
-	return nil
+	cw.Flush()
+	return cw.Error()
+}
+
+// usageMetrics lists resource usage columns for CSV output.
+var usageMetrics = []struct {
+	name string
+	get  func(runner.UsageStats) runner.Summary
+}{
+	{"user_ns", func(u runner.UsageStats) runner.Summary { return u.UserTime }},
+	{"sys_ns", func(u runner.UsageStats) runner.Summary { return u.SysTime }},
+	{"maxrss_bytes", func(u runner.UsageStats) runner.Summary { return u.MaxRSS }},
+	{"minflt", func(u runner.UsageStats) runner.Summary { return u.MinorFaults }},
+	{"majflt", func(u runner.UsageStats) runner.Summary { return u.MajorFaults }},
+	{"nvcsw", func(u runner.UsageStats) runner.Summary { return u.VolCtxSwitches }},
+	{"nivcsw", func(u runner.UsageStats) runner.Summary { return u.InvolCtxSwitches }},
 }
 
 // WriteSummary writes a brief summary comparing AWK implementations.
@@ -124,11 +253,18 @@ func WriteSummary(w io.Writer, results []runner.BenchmarkResult) error {
 		return nil
 	}
 
-	// Aggregate by AWK
+	// Aggregate by AWK (failed cells and startup probes are not ranked)
+	_, results = splitStartup(results)
 	byAWK := make(map[string][]time.Duration)
 	for _, r := range results {
+		if r.Failed {
+			continue
+		}
 		byAWK[r.AWK] = append(byAWK[r.AWK], r.Mean)
 	}
+	if len(byAWK) == 0 {
+		return nil
+	}
 
 	// Calculate geometric mean for each AWK
 	type awkScore struct {
@@ -138,12 +274,12 @@ func WriteSummary(w io.Writer, results []runner.BenchmarkResult) error {
 	scores := make([]awkScore, 0, len(byAWK))
 
 	for awk, durations := range byAWK {
-		// Geometric mean
-		product := 1.0
+		// Geometric mean, via logs so that many cells cannot overflow
+		var logSum float64
 		for _, d := range durations {
-			product *= float64(d.Nanoseconds())
+			logSum += math.Log(float64(d.Nanoseconds()))
 		}
-		geoMean := pow(product, 1.0/float64(len(durations)))
+		geoMean := math.Exp(logSum / float64(len(durations)))
 		scores = append(scores, awkScore{awk, geoMean})
 	}
 
@@ -167,19 +303,127 @@ func WriteSummary(w io.Writer, results []runner.BenchmarkResult) error {
 	return nil
 }
 
+// compareToFastest describes how r compares to the fastest AWK: the ratio
+// of medians with its bootstrap CI, or "~" if a Mann-Whitney U test finds
+// no significant difference (like benchstat).
+func compareToFastest(fastest, r runner.BenchmarkResult) string {
+	a := runner.Seconds(r.Kept())
+	b := runner.Seconds(fastest.Kept())
+	if len(a) < 2 || len(b) < 2 {
+		return fmt.Sprintf("%.2fx", float64(r.Median)/float64(fastest.Median))
+	}
+
+	_, p := stats.MannWhitney(a, b)
+	if p >= stats.Alpha {
+		return fmt.Sprintf("~ no significant difference (p=%.2f)", p)
+	}
+	rng := rand.New(rand.NewSource(1))
+	ratio, lo, hi := stats.RatioCI(a, b, stats.DefaultIterations, 0.95, rng)
+	return fmt.Sprintf("%.2fx [%.2f, %.2f] (%s)", ratio, lo, hi, formatP(p))
+}
+
+func formatP(p float64) string {
+	if p < 0.001 {
+		return "p<0.001"
+	}
+	return fmt.Sprintf("p=%.3f", p)
+}
+
+// splitFailed separates ranked results from cells that failed verification.
+func splitFailed(results []runner.BenchmarkResult) (ok, failed []runner.BenchmarkResult) {
+	for _, r := range results {
+		if r.Failed {
+			failed = append(failed, r)
+		} else {
+			ok = append(ok, r)
+		}
+	}
+	return ok, failed
+}
+
+// groupByProgram groups the ranked results that keep accepts by program
+// and variant, and returns the groups with their sorted keys.
+func groupByProgram(results []runner.BenchmarkResult, keep func(runner.BenchmarkResult) bool) (map[string][]runner.BenchmarkResult, []string) {
+	groups := make(map[string][]runner.BenchmarkResult)
+	var keys []string
+	for _, r := range results {
+		if r.Failed || !keep(r) {
+			continue
+		}
+		key := r.Program + variant(r)
+		if groups[key] == nil {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], r)
+	}
+	sort.Strings(keys)
+	return groups, keys
+}
+
+// formatRuns shows the run count with the achieved precision of the median.
+func formatRuns(r runner.BenchmarkResult) string {
+	if r.Precision == 0 {
+		return fmt.Sprintf("%d", r.Runs)
+	}
+	return fmt.Sprintf("%d (±%.1f%%)", r.Runs, r.Precision*100)
+}
+
+// formatResidency shows the range of mean input residency across results.
+func formatResidency(results []runner.BenchmarkResult) string {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, r := range results {
+		if r.Residency < 0 {
+			continue
+		}
+		lo = math.Min(lo, r.Residency)
+		hi = math.Max(hi, r.Residency)
+	}
+	switch {
+	case math.IsInf(lo, 1):
+		return "not measured"
+	case lo == hi:
+		return fmt.Sprintf("%.1f%%", lo*100)
+	default:
+		return fmt.Sprintf("%.1f%%–%.1f%%", lo*100, hi*100)
+	}
+}
+
+// formatOutliers shows the outlier count, marking excluded ones.
+func formatOutliers(r runner.BenchmarkResult) string {
+	switch {
+	case len(r.Outliers) == 0:
+		return "0"
+	case r.Robust:
+		return fmt.Sprintf("%d (excluded)", len(r.Outliers))
+	default:
+		return fmt.Sprintf("%d", len(r.Outliers))
+	}
+}
+
 func formatDuration(d time.Duration) string {
 	switch {
+	case d < 0:
+		return "-" + formatDuration(-d)
 	case d < time.Microsecond:
 		return fmt.Sprintf("%.0fns", float64(d.Nanoseconds()))
 	case d < time.Millisecond:
-		return fmt.Sprintf("%.1fµs", float64(d.Microseconds()))
+		return fmt.Sprintf("%.1fµs", float64(d.Nanoseconds())/1e3)
 	case d < time.Second:
-		return fmt.Sprintf("%.1fms", float64(d.Milliseconds()))
+		return fmt.Sprintf("%.1fms", float64(d.Microseconds())/1e3)
 	default:
 		return fmt.Sprintf("%.2fs", d.Seconds())
 	}
 }
 
-func pow(x, y float64) float64 {
-	return math.Pow(x, y)
+func formatBytes(n int64) string {
+	switch {
+	case n < 1<<10:
+		return fmt.Sprintf("%dB", n)
+	case n < 1<<20:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	case n < 1<<30:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	default:
+		return fmt.Sprintf("%.2fGB", float64(n)/(1<<30))
+	}
 }
@@ -0,0 +1,180 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kolkov/uawk-bench/internal/runner"
+	"github.com/kolkov/uawk-bench/internal/sweep"
+)
+
+// scalingKnobs lists the sweep parameters reported as scaling curves.
+var scalingKnobs = []string{sweep.Workers, sweep.GOMAXPROCS}
+
+// isScalingKnob reports whether a sweep parameter is a scaling knob.
+func isScalingKnob(name string) bool {
+	for _, k := range scalingKnobs {
+		if k == name {
+			return true
+		}
+	}
+	return false
+}
+
+// scalingPoint is one parallelism level of a curve.
+type scalingPoint struct {
+	n      int
+	result runner.BenchmarkResult
+}
+
+// scalingCurve is the results of one program and AWK at increasing
+// parallelism, sorted by n. The first point must be n = 1.
+type scalingCurve struct {
+	knob   string
+	name   string // Program, settings and AWK, e.g. "sum.awk: uawk"
+	points []scalingPoint
+}
+
+// speedup returns the sequential median divided by the median at p.
+func (c scalingCurve) speedup(p scalingPoint) float64 {
+	return float64(c.points[0].result.Median) / float64(p.result.Median)
+}
+
+// scalingCurves collects the curves of every scaling knob: results that
+// differ only in the knob value, with a sequential (1) point.
+func scalingCurves(results []runner.BenchmarkResult) []scalingCurve {
+	var curves []scalingCurve
+	for _, knob := range scalingKnobs {
+		byName := make(map[string]*scalingCurve)
+		var names []string
+		for _, r := range results {
+			v, ok := r.Params[knob]
+			if !ok || r.Failed || r.Median <= 0 {
+				continue
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				continue
+			}
+			name := r.Program + variant(r) + ": " + curveAWK(r.Params, knob)
+			c, ok := byName[name]
+			if !ok {
+				c = &scalingCurve{knob: knob, name: name}
+				byName[name] = c
+				names = append(names, name)
+			}
+			c.points = append(c.points, scalingPoint{n, r})
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			c := byName[name]
+			sort.Slice(c.points, func(i, j int) bool { return c.points[i].n < c.points[j].n })
+			if len(c.points) > 1 && c.points[0].n == 1 {
+				curves = append(curves, *c)
+			}
+		}
+	}
+	return curves
+}
+
+// curveAWK names the AWK of a scaling curve by its other parameters,
+// e.g. "uawk --no-posix".
+func curveAWK(params runner.Params, knob string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != knob && k != sweep.AWKParam {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	parts := []string{params[sweep.AWKParam]}
+	for _, k := range keys {
+		parts = append(parts, params[k])
+	}
+	return strings.Join(parts, " ")
+}
+
+// writeScaling writes a speedup and efficiency table with a bar chart for
+// every scaling curve.
+func writeScaling(w io.Writer, results []runner.BenchmarkResult) {
+	curves := scalingCurves(results)
+	if len(curves) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "## Scaling\n\n")
+	fmt.Fprintf(w, "Speedup is the median at 1 divided by the median at N; efficiency is speedup / N.\n\n")
+	for _, c := range curves {
+		fmt.Fprintf(w, "### %s (%s)\n\n", c.name, c.knob)
+		fmt.Fprintf(w, "| %s | CPUs | Median [95%% CI] | Speedup | Efficiency |\n", c.knob)
+		fmt.Fprintf(w, "|---|------|-----------------|---------|------------|\n")
+		for _, p := range c.points {
+			r := p.result
+			cpus := "-"
+			if len(r.CPUs) > 0 {
+				cpus = runner.FormatCPUList(r.CPUs)
+			}
+			s := c.speedup(p)
+			fmt.Fprintf(w, "| %d | %s | %s [%s, %s] | %.2fx | %.0f%% |\n",
+				p.n, cpus,
+				formatDuration(r.Median), formatDuration(r.MedianLow), formatDuration(r.MedianHigh),
+				s, 100*s/float64(p.n))
+		}
+		fmt.Fprintf(w, "\n```\n")
+		writeScalingChart(w, c)
+		fmt.Fprintf(w, "```\n\n")
+	}
+}
+
+// chartWidth is the width of the longest bar in scaling charts.
+const chartWidth = 40
+
+// writeScalingChart draws the speedup of each point as a bar, with "|"
+// marking linear speedup where it fits the scale.
+func writeScalingChart(w io.Writer, c scalingCurve) {
+	top := float64(c.points[len(c.points)-1].n) // Linear speedup at the last point
+	for _, p := range c.points {
+		top = max(top, c.speedup(p))
+	}
+	for _, p := range c.points {
+		s := c.speedup(p)
+		bar := []rune(strings.Repeat("█", int(s/top*chartWidth+0.5)) + strings.Repeat(" ", chartWidth))[:chartWidth+1]
+		if ideal := int(float64(p.n)/top*chartWidth + 0.5); ideal <= chartWidth && bar[ideal] == ' ' {
+			bar[ideal] = '|'
+		}
+		fmt.Fprintf(w, "%3d %s %.2fx\n", p.n, string(bar), s)
+	}
+}
+
+// WriteScalingCSV writes the scaling curves as CSV, one row per point.
+// It writes nothing if there are no curves.
+func WriteScalingCSV(w io.Writer, results []runner.BenchmarkResult) error {
+	curves := scalingCurves(results)
+	if len(curves) == 0 {
+		return nil
+	}
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"program", "awk", "knob", "n", "cpus", "median_ns", "median_ci_low_ns", "median_ci_high_ns", "speedup", "efficiency"})
+	for _, c := range curves {
+		for _, p := range c.points {
+			r := p.result
+			s := c.speedup(p)
+			cw.Write([]string{
+				r.Program, r.AWK, c.knob, fmt.Sprint(p.n), runner.FormatCPUList(r.CPUs),
+				fmt.Sprint(r.Median.Nanoseconds()), fmt.Sprint(r.MedianLow.Nanoseconds()), fmt.Sprint(r.MedianHigh.Nanoseconds()),
+				fmt.Sprintf("%.4f", s), fmt.Sprintf("%.4f", s/float64(p.n)),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// HasScaling reports whether results contain a scaling curve.
+func HasScaling(results []runner.BenchmarkResult) bool {
+	return len(scalingCurves(results)) > 0
+}
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kolkov/uawk-bench/internal/runner"
+)
+
+// splitStartup separates startup probe results from benchmark results.
+func splitStartup(results []runner.BenchmarkResult) (startup, rest []runner.BenchmarkResult) {
+	for _, r := range results {
+		if r.Program == runner.StartupProgram {
+			startup = append(startup, r)
+		} else {
+			rest = append(rest, r)
+		}
+	}
+	return startup, rest
+}
+
+// writeStartup writes the startup cost of each AWK (BEGIN{} without
+// input) and how much of it exceeds the harness baseline.
+func writeStartup(w io.Writer, startup []runner.BenchmarkResult) {
+	if len(startup) == 0 {
+		return
+	}
+	sort.Slice(startup, func(i, j int) bool {
+		return startup[i].Median < startup[j].Median
+	})
+
+	var harness *runner.BenchmarkResult
+	for i := range startup {
+		if startup[i].AWK == runner.HarnessName {
+			harness = &startup[i]
+		}
+	}
+
+	fmt.Fprintf(w, "## Startup (%s, no input)\n\n", runner.StartupProgram)
+	fmt.Fprintf(w, "| AWK | Median [95%% CI] | Over Harness | Runs | User | Sys | Peak RSS |\n")
+	fmt.Fprintf(w, "|-----|-----------------|--------------|------|------|-----|----------|\n")
+	for _, r := range startup {
+		over := "-"
+		if harness != nil && r.AWK != runner.HarnessName {
+			over = formatUncertain(r.Median-harness.Median, math.Hypot(r.HalfWidth(), harness.HalfWidth()))
+		}
+		u := r.Usage
+		fmt.Fprintf(w, "| %s | %s [%s, %s] | %s | %s | %s | %s | %s |\n",
+			r.AWK+variant(r),
+			formatDuration(r.Median), formatDuration(r.MedianLow), formatDuration(r.MedianHigh),
+			over,
+			formatRuns(r),
+			formatDuration(time.Duration(u.UserTime.Median)),
+			formatDuration(time.Duration(u.SysTime.Median)),
+			formatBytes(u.MaxRSS.Max),
+		)
+	}
+	fmt.Fprintf(w, "\n")
+	if harness != nil {
+		fmt.Fprintf(w, "%s is /bin/true: the cost of fork, exec and wait in this harness.\n\n", runner.HarnessName)
+	}
+}
+
+// formatUncertain formats a duration with its 95% uncertainty.
+func formatUncertain(d time.Duration, err float64) string {
+	return fmt.Sprintf("%s ± %s", formatDuration(d), formatDuration(time.Duration(err)))
+}
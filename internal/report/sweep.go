@@ -0,0 +1,126 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/kolkov/uawk-bench/internal/runner"
+)
+
+// writeSweep writes a comparison for every sweep parameter that takes more
+// than one value. Results that differ only in that parameter form a group;
+// each level is compared to the first by the geometric mean of the median
+// ratios over all groups, and by how often it is the fastest of its group.
+func writeSweep(w io.Writer, results []runner.BenchmarkResult) {
+	for _, name := range paramNames(results) {
+		if isScalingKnob(name) {
+			continue // See writeScaling
+		}
+		var swept []runner.BenchmarkResult
+		for _, r := range results {
+			if _, ok := r.Params[name]; ok {
+				swept = append(swept, r)
+			}
+		}
+		levels := distinct(swept, func(r runner.BenchmarkResult) string { return r.Params[name] })
+		if len(levels) < 2 {
+			continue
+		}
+
+		// Group by program, settings and the other parameters
+		groups := make(map[string]map[string]runner.BenchmarkResult)
+		for _, r := range swept {
+			if r.Failed {
+				continue
+			}
+			key := r.Program + variant(r) + " " + formatParamsWithout(r.Params, name)
+			if groups[key] == nil {
+				groups[key] = make(map[string]runner.BenchmarkResult)
+			}
+			groups[key][r.Params[name]] = r
+		}
+
+		base := levels[0]
+		logSum := make(map[string]float64)
+		compared := make(map[string]int)
+		wins := make(map[string]int)
+		cells := make(map[string]int)
+		for _, g := range groups {
+			fastest := ""
+			for _, level := range levels {
+				r, ok := g[level]
+				if !ok {
+					continue
+				}
+				cells[level]++
+				if fastest == "" || r.Median < g[fastest].Median {
+					fastest = level
+				}
+				if b, ok := g[base]; ok && b.Median > 0 && r.Median > 0 {
+					logSum[level] += math.Log(float64(r.Median) / float64(b.Median))
+					compared[level]++
+				}
+			}
+			if len(g) > 1 {
+				wins[fastest]++
+			}
+		}
+		contested := 0
+		for _, g := range groups {
+			if len(g) > 1 {
+				contested++
+			}
+		}
+		if contested == 0 {
+			continue // No results differ only in this parameter
+		}
+
+		fmt.Fprintf(w, "## Sweep: %s\n\n", name)
+		fmt.Fprintf(w, "| Level | Median vs %s (geomean) | Fastest | Cells |\n", base)
+		fmt.Fprintf(w, "|-------|------------------------|---------|-------|\n")
+		for _, level := range levels {
+			ratio := "-"
+			if n := compared[level]; n > 0 {
+				ratio = fmt.Sprintf("%.3fx", math.Exp(logSum[level]/float64(n)))
+			}
+			fmt.Fprintf(w, "| %s | %s | %d/%d | %d |\n", level, ratio, wins[level], contested, cells[level])
+		}
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+// paramNames returns the sweep parameters used by results, sorted.
+func paramNames(results []runner.BenchmarkResult) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, r := range results {
+		for k := range r.Params {
+			if !seen[k] {
+				seen[k] = true
+				names = append(names, k)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatParams formats sweep parameters as "k=v;k=v", sorted by name.
+func formatParams(params runner.Params) string {
+	return formatParamsWithout(params, "")
+}
+
+// formatParamsWithout formats sweep parameters other than skip.
+func formatParamsWithout(params runner.Params, skip string) string {
+	parts := make([]string, 0, len(params))
+	for k, v := range params {
+		if k != skip {
+			parts = append(parts, k+"="+v)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,61 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/kolkov/uawk-bench/internal/runner"
+)
+
+// writeReadRate writes the read throughput of every sampled cell early
+// and late in its runs, flagging cells that slow down.
+func writeReadRate(w io.Writer, results []runner.BenchmarkResult) {
+	byProgram, programs := groupByProgram(results, func(r runner.BenchmarkResult) bool { return r.ReadRate != nil })
+	if len(programs) == 0 {
+		return
+	}
+	var flagged []string
+	threshold := byProgram[programs[0]][0].ReadRate.Threshold
+	for _, prog := range programs {
+		for _, r := range byProgram[prog] {
+			if r.ReadRate.Flagged {
+				flagged = append(flagged, fmt.Sprintf("%s on %s", r.AWK, prog))
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "## Read Throughput Over Time\n\n")
+	if len(flagged) > 0 {
+		sort.Strings(flagged)
+		fmt.Fprintf(w, "Slower by more than %.0f%% in the last quarter of reading than in the first:\n\n", 100*threshold)
+		for _, f := range flagged {
+			fmt.Fprintf(w, "- **%s**\n", f)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	for _, prog := range programs {
+		cells := byProgram[prog]
+		sort.Slice(cells, func(i, j int) bool { return cells[i].Median < cells[j].Median })
+
+		fmt.Fprintf(w, "### %s\n\n", prog)
+		fmt.Fprintf(w, "| AWK | First Quarter | Last Quarter | Change | Throughput |\n")
+		fmt.Fprintf(w, "|-----|---------------|--------------|--------|------------|\n")
+		for _, r := range cells {
+			rr := r.ReadRate
+			rates := make([]float64, len(rr.Timeline))
+			for i, rate := range rr.Timeline {
+				rates[i] = rate.MBps
+			}
+			change := fmt.Sprintf("%+.0f%%", -100*rr.Drop)
+			if rr.Flagged {
+				change = "**" + change + "**"
+			}
+			fmt.Fprintf(w, "| %s | %.1f MB/s | %.1f MB/s | %s | `%s` |\n",
+				r.AWK, rr.FirstQuarter, rr.LastQuarter, change, sparkline(rates, sparkWidth))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	fmt.Fprintf(w, "Throughput is rchar (bytes returned by read calls) per second, up to the end of reading; ")
+	fmt.Fprintf(w, "quarters are medians over runs and the sparkline shows the run with the median time.\n\n")
+}
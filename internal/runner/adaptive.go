@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// z95 is the two-sided 95% normal quantile.
+const z95 = 1.959964
+
+// needsMore reports whether a cell should get another measured run.
+//
+// With a fixed run count (TargetCI == 0) every cell gets exactly Runs
+// measured runs (runs lost with FailContinue included). In adaptive mode
+// sampling continues until the relative CI half-width of the median
+// drops below TargetCI, bounded by MinRuns, MaxRuns and the per-cell
+// time Budget.
+func (r *Runner) needsMore(st *cellState) bool {
+	if st.err != nil {
+		return false
+	}
+	n := len(st.samples) + st.lost // Runs given up on still count
+	if r.TargetCI <= 0 {
+		return n < r.Runs
+	}
+
+	if n < r.minRuns() {
+		return true
+	}
+	if len(st.samples) == 0 {
+		return false // Every run failed
+	}
+	if r.MaxRuns > 0 && n >= r.MaxRuns {
+		return false
+	}
+	if r.Budget > 0 && st.elapsed >= r.Budget {
+		return false
+	}
+	p, ok := relativePrecision(sampleDurations(st.samples))
+	return !ok || p > r.TargetCI
+}
+
+// minRuns returns the minimum number of samples in adaptive mode.
+func (r *Runner) minRuns() int {
+	if r.MinRuns > 0 {
+		return r.MinRuns
+	}
+	return r.Runs
+}
+
+// medianCI returns a distribution-free 95% confidence interval for the
+// median, based on order statistics (normal approximation to the
+// binomial). ok is false if there are too few samples (n < 8).
+func medianCI(durations []time.Duration) (lo, hi time.Duration, ok bool) {
+	n := len(durations)
+	sorted := make([]time.Duration, n)
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	half := z95 * math.Sqrt(float64(n)) / 2
+	j := int(math.Floor(float64(n)/2 - half)) // 1-based rank of the lower bound
+	k := int(math.Ceil(float64(n)/2 + half))  // 1-based rank of the upper bound
+	if j < 1 || k > n {
+		return 0, 0, false
+	}
+	return sorted[j-1], sorted[k-1], true
+}
+
+// relativePrecision returns the half-width of the median's 95% CI
+// relative to the median.
+func relativePrecision(durations []time.Duration) (float64, bool) {
+	lo, hi, ok := medianCI(durations)
+	if !ok {
+		return 0, false
+	}
+	median := medianOf(durations)
+	if median <= 0 {
+		return 0, false
+	}
+	return float64(hi-lo) / 2 / float64(median), true
+}
+
+// medianOf returns the median of durations.
+func medianOf(durations []time.Duration) time.Duration {
+	n := len(durations)
+	if n == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, n)
+	copy(sorted, durations)
+	sortDurations(sorted)
+	if n%2 == 0 {
+		return (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return sorted[n/2]
+}
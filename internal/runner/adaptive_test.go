@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMedianCI(t *testing.T) {
+	tests := []struct {
+		name   string
+		ms     []int
+		lo, hi time.Duration
+		ok     bool
+	}{
+		{"too few", msRange(1, 7), 0, 0, false},
+		{"smallest", msRange(1, 8), 1 * time.Millisecond, 7 * time.Millisecond, true},
+		{"unsorted", []int{8, 3, 5, 1, 7, 2, 6, 4}, 1 * time.Millisecond, 7 * time.Millisecond, true},
+		{"n=20", msRange(1, 20), 5 * time.Millisecond, 15 * time.Millisecond, true},
+		{"n=100", msRange(1, 100), 40 * time.Millisecond, 60 * time.Millisecond, true},
+	}
+	for _, tt := range tests {
+		lo, hi, ok := medianCI(durations(tt.ms))
+		if lo != tt.lo || hi != tt.hi || ok != tt.ok {
+			t.Errorf("%s: medianCI = %v, %v, %v; want %v, %v, %v", tt.name, lo, hi, ok, tt.lo, tt.hi, tt.ok)
+		}
+	}
+}
+
+func TestNeedsMore(t *testing.T) {
+	fixed := &Runner{Runs: 5}
+	adaptive := &Runner{Runs: 5, TargetCI: 0.05, MinRuns: 3, MaxRuns: 10, Budget: time.Second}
+	tests := []struct {
+		name string
+		r    *Runner
+		st   cellState
+		want bool
+	}{
+		{"fixed short", fixed, cellState{samples: samples(10, 10, 10, 10)}, true},
+		{"fixed done", fixed, cellState{samples: samples(10, 10, 10, 10, 10)}, false},
+		{"fixed lost runs count", fixed, cellState{samples: samples(10, 10, 10), lost: 2}, false},
+		{"aborted", fixed, cellState{err: errors.New("timeout")}, false},
+		{"below minimum", adaptive, cellState{samples: samples(10, 10)}, true},
+		{"every run failed", adaptive, cellState{lost: 3}, false},
+		{"no CI yet", adaptive, cellState{samples: samples(10, 10, 10, 10, 10, 10, 10)}, true},
+		{"precise", adaptive, cellState{samples: samples(10, 10, 10, 10, 10, 10, 10, 10)}, false},
+		{"imprecise", adaptive, cellState{samples: samples(5, 10, 15, 20, 5, 10, 15, 20)}, true},
+		{"max runs", adaptive, cellState{samples: samples(5, 10, 15, 20, 5, 10, 15, 20, 5, 10)}, false},
+		{"budget spent", adaptive, cellState{samples: samples(5, 10, 15, 20, 5, 10, 15, 20), elapsed: time.Second}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.r.needsMore(&tt.st); got != tt.want {
+			t.Errorf("%s: needsMore = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// msRange returns the milliseconds from..to.
+func msRange(from, to int) []int {
+	var ms []int
+	for i := from; i <= to; i++ {
+		ms = append(ms, i)
+	}
+	return ms
+}
+
+// durations converts milliseconds to durations.
+func durations(ms []int) []time.Duration {
+	ds := make([]time.Duration, len(ms))
+	for i, m := range ms {
+		ds[i] = time.Duration(m) * time.Millisecond
+	}
+	return ds
+}
+
+// samples returns successful samples of the given milliseconds.
+func samples(ms ...int) []Sample {
+	var s []Sample
+	for _, d := range durations(ms) {
+		s = append(s, Sample{Duration: d})
+	}
+	return s
+}
@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Parallelism returns the number of workers the AWK is configured to use:
+// the value of a "-j N" argument or, without one, of GOMAXPROCS in Env.
+// It is 1 otherwise.
+func (a AWK) Parallelism() int {
+	for i, arg := range a.Args {
+		var value string
+		switch {
+		case arg == "-j" && i+1 < len(a.Args):
+			value = a.Args[i+1]
+		case strings.HasPrefix(arg, "-j"):
+			value = arg[2:]
+		default:
+			continue
+		}
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+	}
+	for _, kv := range a.Env {
+		if value, ok := strings.CutPrefix(kv, "GOMAXPROCS="); ok {
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	return 1
+}
+
+// cpuSet returns the CPUs a child of awk is pinned to, or nil.
+// An explicit AWK.CPUs wins; otherwise the AWK gets as many CPUs from
+// the start of Runner.CPUs as its Parallelism.
+func (r *Runner) cpuSet(awk AWK) []int {
+	if len(awk.CPUs) > 0 {
+		return awk.CPUs
+	}
+	if len(r.CPUs) == 0 {
+		return nil
+	}
+	return r.CPUs[:min(awk.Parallelism(), len(r.CPUs))]
+}
+
+// ParseCPUList parses a CPU list such as "0,2,4-7".
+func ParseCPUList(s string) ([]int, error) {
+	var cpus []int
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		loStr, hiStr, isRange := strings.Cut(part, "-")
+		lo, err := strconv.Atoi(loStr)
+		if err != nil || lo < 0 {
+			return nil, fmt.Errorf("invalid CPU %q", part)
+		}
+		hi := lo
+		if isRange {
+			hi, err = strconv.Atoi(hiStr)
+			if err != nil || hi < lo {
+				return nil, fmt.Errorf("invalid CPU range %q", part)
+			}
+		}
+		for cpu := lo; cpu <= hi; cpu++ {
+			if !seen[cpu] {
+				seen[cpu] = true
+				cpus = append(cpus, cpu)
+			}
+		}
+	}
+	return cpus, nil
+}
+
+// FormatCPUList formats CPUs in the list syntax accepted by ParseCPUList.
+func FormatCPUList(cpus []int) string {
+	sorted := append([]int(nil), cpus...)
+	sort.Ints(sorted)
+
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(sorted[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
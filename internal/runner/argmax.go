@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"unsafe"
+)
+
+// CheckArgs returns an error if the arguments and environment of a
+// cell's runs would exceed ARG_MAX, as operands naming many small files
+// can. The list input mode passes inputs in a file instead.
+func (r *Runner) CheckArgs(c Cell) error {
+	args := append([]string{c.AWK.Command}, c.AWK.Args...)
+	args = append(args, "-f", c.Program)
+	switch r.inputMode(c) {
+	case InputFile:
+		args = append(args, c.Inputs...)
+	case InputFIFO:
+		// The longest directory name os.MkdirTemp can choose
+		dir := filepath.Join(os.TempDir(), fifoDirPattern+strconv.FormatUint(1<<32-1, 10))
+		for i := range c.Inputs {
+			args = append(args, fifoName(dir, i))
+		}
+	}
+	env := r.environ(withLocale(c.AWK, c.Locale))
+
+	if size, limit := argSize(args, env), argMax(); size > limit {
+		return fmt.Errorf("%d arguments and the environment take %d bytes, over ARG_MAX (%d); use the list input mode",
+			len(args), size, limit)
+	}
+	return nil
+}
+
+// argSize returns the space execve needs for args and env: the strings
+// with their terminating NULs and a pointer to each.
+func argSize(args, env []string) int {
+	size := 0
+	for _, list := range [][]string{args, env} {
+		for _, s := range list {
+			size += len(s) + 1 + int(unsafe.Sizeof(uintptr(0)))
+		}
+	}
+	return size
+}
@@ -0,0 +1,16 @@
+package runner
+
+import "syscall"
+
+// argMax returns the space the kernel allows for exec arguments and
+// environment: a quarter of the stack limit, capped at 6 MiB (3/4 of
+// _STK_LIM) and at least 128 KiB. sysconf(_SC_ARG_MAX) in glibc
+// reports the same without the cap.
+func argMax() int {
+	const floor, ceiling = 128 << 10, 6 << 20
+	var rlim syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_STACK, &rlim); err != nil {
+		return floor
+	}
+	return int(max(floor, min(rlim.Cur/4, ceiling)))
+}
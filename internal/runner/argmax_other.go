@@ -0,0 +1,9 @@
+//go:build !linux
+
+package runner
+
+// argMax returns a conservative limit for exec arguments and
+// environment; ARG_MAX is 256 KiB on the BSDs and 1 MiB on macOS.
+func argMax() int {
+	return 256 << 10
+}
@@ -0,0 +1,90 @@
+package runner
+
+import "fmt"
+
+// CacheMode controls whether the input is in the page cache during runs.
+type CacheMode string
+
+const (
+	// CacheDefault leaves the page cache alone: the first warmup run
+	// usually pulls the input in and measured runs are hot.
+	CacheDefault CacheMode = "default"
+	// CacheHot preloads the input before every run and checks with
+	// mincore that it is resident.
+	CacheHot CacheMode = "hot"
+	// CacheCold evicts the input with posix_fadvise(DONTNEED) before
+	// every run, so the AWK reads from disk.
+	CacheCold CacheMode = "cold"
+)
+
+// ParseCacheMode converts a cache mode name to a CacheMode.
+func ParseCacheMode(s string) (CacheMode, error) {
+	switch CacheMode(s) {
+	case CacheDefault, CacheHot, CacheCold:
+		return CacheMode(s), nil
+	case "":
+		return CacheDefault, nil
+	default:
+		return "", fmt.Errorf("unknown cache mode %q (use default, hot, cold)", s)
+	}
+}
+
+// prepareCache brings the input files into the state required by the
+// cache mode and returns the mean fraction of their pages resident
+// afterwards (-1 if not measured). In hot mode an input that is not
+// fully resident is an error.
+func (r *Runner) prepareCache(paths []string) (float64, error) {
+	if r.cacheMode() == CacheDefault || len(paths) == 0 {
+		return -1, nil
+	}
+
+	var sum float64
+	for _, path := range paths {
+		if r.CacheMode == CacheHot {
+			if err := preload(path); err != nil {
+				return -1, fmt.Errorf("preloading %s: %w", path, err)
+			}
+		} else {
+			if err := evict(path); err != nil {
+				return -1, fmt.Errorf("evicting %s: %w", path, err)
+			}
+		}
+
+		resident, err := residency(path)
+		if err != nil {
+			return -1, fmt.Errorf("mincore %s: %w", path, err)
+		}
+		if r.CacheMode == CacheHot && resident < 1 {
+			// Not enough memory to keep the inputs cached: the runs
+			// would read part of them from disk
+			return -1, fmt.Errorf("%s is only %.1f%% resident after preloading", path, 100*resident)
+		}
+		sum += resident
+	}
+	return sum / float64(len(paths)), nil
+}
+
+// cacheMode returns the effective cache mode.
+func (r *Runner) cacheMode() CacheMode {
+	if r.CacheMode == "" {
+		return CacheDefault
+	}
+	return r.CacheMode
+}
+
+// meanResidency averages the input residency measured before each run,
+// or returns -1 if it was not measured.
+func meanResidency(samples []Sample) float64 {
+	var sum float64
+	var n int
+	for _, s := range samples {
+		if s.Residency >= 0 {
+			sum += s.Residency
+			n++
+		}
+	}
+	if n == 0 {
+		return -1
+	}
+	return sum / float64(n)
+}
@@ -0,0 +1,82 @@
+//go:build linux && (amd64 || arm64 || riscv64 || loong64)
+
+package runner
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const fadvDontNeed = 4 // POSIX_FADV_DONTNEED
+
+// preload reads a file so its pages are in the page cache.
+func preload(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(io.Discard, f)
+	return err
+}
+
+// evict drops a file's pages from the page cache. Dirty pages cannot be
+// dropped, so the file is synced first. No privileges are needed.
+func evict(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fd := int(f.Fd())
+	if err := syscall.Fdatasync(fd); err != nil {
+		return err
+	}
+	_, _, errno := syscall.Syscall6(syscall.SYS_FADVISE64, uintptr(fd), 0, 0, fadvDontNeed, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// residency returns the fraction of a file's pages in the page cache.
+func residency(path string) (float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() == 0 {
+		return 1, nil
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return 0, err
+	}
+	defer syscall.Munmap(data)
+
+	pageSize := os.Getpagesize()
+	pages := (len(data) + pageSize - 1) / pageSize
+	vec := make([]byte, pages)
+	_, _, errno := syscall.Syscall(syscall.SYS_MINCORE,
+		uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)), uintptr(unsafe.Pointer(&vec[0])))
+	if errno != 0 {
+		return 0, errno
+	}
+
+	resident := 0
+	for _, v := range vec {
+		resident += int(v & 1)
+	}
+	return float64(resident) / float64(pages), nil
+}
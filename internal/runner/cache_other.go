@@ -0,0 +1,13 @@
+//go:build !linux || !(amd64 || arm64 || riscv64 || loong64)
+
+package runner
+
+import "errors"
+
+var errCacheControl = errors.New("page cache control is only supported on 64-bit Linux")
+
+func preload(path string) error { return errCacheControl }
+
+func evict(path string) error { return errCacheControl }
+
+func residency(path string) (float64, error) { return 0, errCacheControl }
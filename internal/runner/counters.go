@@ -0,0 +1,77 @@
+package runner
+
+import "time"
+
+// Counters holds the performance counters of one run, summed over the
+// AWK and every thread and process it started (from perf_event_open).
+// Counters that could not be opened are -1.
+type Counters struct {
+	Cycles       int64
+	Instructions int64
+	BranchMisses int64
+	CacheMisses  int64
+	TaskClock    time.Duration // CPU time as counted by the kernel
+	UserOnly     bool          // Kernel time excluded (perf_event_paranoid)
+}
+
+// CounterStats aggregates performance counters across runs. Summaries
+// cover the runs where a counter was available; derived ratios are 0
+// when their inputs are missing.
+type CounterStats struct {
+	Cycles       Summary
+	Instructions Summary
+	BranchMisses Summary
+	CacheMisses  Summary
+	TaskClock    Summary
+	UserOnly     bool
+
+	IPC                 float64 // Median instructions / median cycles
+	InstructionsPerByte float64 // Median instructions / input bytes
+	BranchMissesPerKI   float64 // Median branch misses per 1000 instructions
+	CacheMissesPerKI    float64 // Median cache misses per 1000 instructions
+}
+
+// summarizeCounters aggregates the counters of samples, or returns nil
+// if none were collected.
+func summarizeCounters(samples []Sample, inputSize int64) *CounterStats {
+	var all []Counters
+	for _, s := range samples {
+		if s.Counters != nil {
+			all = append(all, *s.Counters)
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+
+	metric := func(get func(Counters) int64) Summary {
+		var values []int64
+		for _, c := range all {
+			if v := get(c); v >= 0 {
+				values = append(values, v)
+			}
+		}
+		return summarize(values)
+	}
+	cs := &CounterStats{
+		Cycles:       metric(func(c Counters) int64 { return c.Cycles }),
+		Instructions: metric(func(c Counters) int64 { return c.Instructions }),
+		BranchMisses: metric(func(c Counters) int64 { return c.BranchMisses }),
+		CacheMisses:  metric(func(c Counters) int64 { return c.CacheMisses }),
+		TaskClock:    metric(func(c Counters) int64 { return int64(c.TaskClock) }),
+		UserOnly:     all[0].UserOnly,
+	}
+
+	instr := float64(cs.Instructions.Median)
+	if instr > 0 {
+		if cs.Cycles.Median > 0 {
+			cs.IPC = instr / float64(cs.Cycles.Median)
+		}
+		if inputSize > 0 {
+			cs.InstructionsPerByte = instr / float64(inputSize)
+		}
+		cs.BranchMissesPerKI = float64(cs.BranchMisses.Median) / instr * 1000
+		cs.CacheMissesPerKI = float64(cs.CacheMisses.Median) / instr * 1000
+	}
+	return cs
+}
@@ -0,0 +1,211 @@
+//go:build linux
+
+package runner
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// perfEventAttr is struct perf_event_attr up to config1
+// (PERF_ATTR_SIZE_VER0).
+type perfEventAttr struct {
+	Type         uint32
+	Size         uint32
+	Config       uint64
+	SamplePeriod uint64
+	SampleType   uint64
+	ReadFormat   uint64
+	Flags        uint64
+	WakeupEvents uint32
+	BpType       uint32
+	Config1      uint64
+}
+
+const (
+	perfTypeHardware = 0
+	perfTypeSoftware = 1
+
+	perfCountHWCPUCycles    = 0
+	perfCountHWInstructions = 1
+	perfCountHWCacheMisses  = 3
+	perfCountHWBranchMisses = 5
+	perfCountSWTaskClock    = 1
+
+	perfFormatTotalTimeEnabled = 1 << 0
+	perfFormatTotalTimeRunning = 1 << 1
+
+	perfFlagDisabled      = 1 << 0
+	perfFlagInherit       = 1 << 1
+	perfFlagExcludeKernel = 1 << 5
+	perfFlagExcludeHV     = 1 << 6
+	perfFlagEnableOnExec  = 1 << 12
+
+	perfFlagFDCloexec = 1 << 3
+)
+
+// perfEvent is one counter of a counterSet.
+type perfEvent struct {
+	name   string
+	typ    uint32
+	config uint64
+}
+
+// perfEvents lists the counters in the order of counterSet.fds.
+var perfEvents = []perfEvent{
+	{"cycles", perfTypeHardware, perfCountHWCPUCycles},
+	{"instructions", perfTypeHardware, perfCountHWInstructions},
+	{"branch-misses", perfTypeHardware, perfCountHWBranchMisses},
+	{"cache-misses", perfTypeHardware, perfCountHWCacheMisses},
+	{"task-clock", perfTypeSoftware, perfCountSWTaskClock},
+}
+
+// counterSet holds the counters of one run. They are opened on the OS
+// thread that starts the AWK, disabled and inherited, with
+// enable-on-exec: the child inherits them and they start counting when
+// it execs the AWK, so the harness's fork and exec are not counted. The
+// counts of the AWK and its descendants add up in the parent counters
+// as they exit.
+type counterSet struct {
+	fds      []int   // -1 = not opened
+	errs     []error // Why a counter was not opened
+	userOnly bool
+}
+
+// newCounterSet returns a counterSet to be opened by startProcess.
+func newCounterSet() *counterSet {
+	return &counterSet{}
+}
+
+// open opens the counters on the calling thread. Counters the kernel
+// refuses are left out; if kernel profiling is not permitted, all
+// counters are user-only.
+func (cs *counterSet) open() {
+	cs.fds = make([]int, len(perfEvents))
+	cs.errs = make([]error, len(perfEvents))
+	for i, ev := range perfEvents {
+		fd, err := perfEventOpen(ev, cs.userOnly)
+		if err == syscall.EACCES && !cs.userOnly {
+			// perf_event_paranoid >= 2 allows user-space counting only
+			cs.userOnly = true
+			fd, err = perfEventOpen(ev, true)
+		}
+		cs.fds[i], cs.errs[i] = fd, err
+	}
+}
+
+// perfEventOpen opens a counter for the calling thread and the tasks it
+// creates.
+func perfEventOpen(ev perfEvent, userOnly bool) (int, error) {
+	attr := perfEventAttr{
+		Type:       ev.typ,
+		Config:     ev.config,
+		ReadFormat: perfFormatTotalTimeEnabled | perfFormatTotalTimeRunning,
+		Flags:      perfFlagDisabled | perfFlagInherit | perfFlagEnableOnExec | perfFlagExcludeHV,
+	}
+	attr.Size = uint32(unsafe.Sizeof(attr))
+	if userOnly {
+		attr.Flags |= perfFlagExcludeKernel
+	}
+	fd, _, errno := syscall.Syscall6(syscall.SYS_PERF_EVENT_OPEN,
+		uintptr(unsafe.Pointer(&attr)), 0, ^uintptr(0), ^uintptr(0), perfFlagFDCloexec, 0) // this thread, any CPU, no group
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(fd), nil
+}
+
+// read returns the counts, scaled up if the kernel multiplexed the
+// counters. It returns nil if no counter was opened.
+func (cs *counterSet) read() *Counters {
+	if cs == nil {
+		return nil
+	}
+	values := make([]int64, len(perfEvents))
+	opened := false
+	for i, fd := range cs.fds {
+		values[i] = -1
+		if fd < 0 {
+			continue
+		}
+		var buf [24]byte // value, time enabled, time running
+		if n, err := syscall.Read(fd, buf[:]); err != nil || n != len(buf) {
+			continue
+		}
+		opened = true
+		value := binary.NativeEndian.Uint64(buf[0:])
+		enabled := binary.NativeEndian.Uint64(buf[8:])
+		running := binary.NativeEndian.Uint64(buf[16:])
+		if running > 0 && running < enabled {
+			value = uint64(float64(value) * float64(enabled) / float64(running))
+		}
+		values[i] = int64(value)
+	}
+	if !opened {
+		return nil
+	}
+	c := &Counters{
+		Cycles:       values[0],
+		Instructions: values[1],
+		BranchMisses: values[2],
+		CacheMisses:  values[3],
+		TaskClock:    time.Duration(values[4]),
+		UserOnly:     cs.userOnly,
+	}
+	if values[4] < 0 {
+		c.TaskClock = -1
+	}
+	return c
+}
+
+// close closes the counters.
+func (cs *counterSet) close() {
+	if cs == nil {
+		return
+	}
+	for _, fd := range cs.fds {
+		if fd >= 0 {
+			syscall.Close(fd)
+		}
+	}
+}
+
+// ProbeCounters checks which performance counters can be opened and
+// returns the unavailable ones with the reason, or an error if none can.
+func ProbeCounters() (unavailable []string, err error) {
+	done := make(chan struct{})
+	var cs counterSet
+	var failures []string
+	go func() {
+		defer close(done)
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		cs.open()
+		for i, err := range cs.errs {
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s (%v)", perfEvents[i].name, err))
+			}
+		}
+	}()
+	<-done
+	cs.close()
+
+	if len(failures) == len(perfEvents) {
+		msg := strings.Join(failures, ", ")
+		if data, err := os.ReadFile("/proc/sys/kernel/perf_event_paranoid"); err == nil {
+			msg += "; perf_event_paranoid=" + strings.TrimSpace(string(data))
+		}
+		return failures, errors.New("no performance counters available: " + msg)
+	}
+	if cs.userOnly {
+		failures = append(failures, "kernel-mode counts (perf_event_paranoid)")
+	}
+	return failures, nil
+}
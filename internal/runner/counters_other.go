@@ -0,0 +1,24 @@
+//go:build !linux
+
+package runner
+
+import "errors"
+
+// counterSet is empty: performance counters need Linux.
+type counterSet struct{}
+
+// newCounterSet returns nil: performance counters need Linux.
+func newCounterSet() *counterSet {
+	return nil
+}
+
+func (cs *counterSet) open() {}
+
+func (cs *counterSet) read() *Counters { return nil }
+
+func (cs *counterSet) close() {}
+
+// ProbeCounters returns an error: performance counters need Linux.
+func ProbeCounters() (unavailable []string, err error) {
+	return nil, errors.New("performance counters are only supported on Linux")
+}
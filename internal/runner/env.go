@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"os"
+	"strings"
+)
+
+// cleanEnvKeys are the variables kept from the harness environment when
+// it is not inherited: enough to find commands and temporary files.
+var cleanEnvKeys = []string{"PATH", "HOME", "TMPDIR", "TEMP", "TMP", "SYSTEMROOT"}
+
+// environ returns the environment of an AWK process: the clean (or, with
+// InheritEnv, the full) harness environment, then LC_ALL set to
+// r.Locale, then the AWK's own variables. Later entries win. With
+// GCTrace, Go AWKs also get gctrace=1 in GODEBUG.
+func (r *Runner) environ(awk AWK) []string {
+	var env []string
+	if r.InheritEnv {
+		env = os.Environ()
+	} else {
+		for _, kv := range os.Environ() {
+			key, _, _ := strings.Cut(kv, "=")
+			for _, keep := range cleanEnvKeys {
+				if strings.EqualFold(key, keep) {
+					env = append(env, kv)
+					break
+				}
+			}
+		}
+	}
+	if r.Locale != "" {
+		env = append(env, "LC_ALL="+r.Locale)
+	}
+	env = append(env, awk.Env...)
+	if r.traceGC(awk) {
+		env = withGCTrace(env)
+	}
+	return env
+}
+
+// traceGC reports whether runs of awk are traced with gctrace.
+func (r *Runner) traceGC(awk AWK) bool {
+	return r.GCTrace && awk.GoRuntime
+}
+
+// withLocale returns a copy of awk that runs under the given locale.
+func withLocale(awk AWK, locale string) AWK {
+	if locale == "" {
+		return awk
+	}
+	awk.Env = append(append([]string{}, awk.Env...), "LC_ALL="+locale)
+	return awk
+}
+
+// locale returns the effective locale of a cell.
+func (r *Runner) locale(c Cell) string {
+	if c.Locale != "" {
+		return c.Locale
+	}
+	return r.Locale
+}
@@ -0,0 +1,16 @@
+//go:build !unix || aix || illumos || solaris
+
+package runner
+
+import (
+	"errors"
+	"runtime"
+)
+
+// mkfifo is not supported without Unix named pipes, nor where package
+// syscall lacks Mkfifo (AIX, illumos, Solaris).
+func mkfifo(path string) error {
+	return errors.New("FIFO input is not supported on " + runtime.GOOS)
+}
+
+func unblockFIFO(path string) {}
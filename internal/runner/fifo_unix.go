@@ -0,0 +1,22 @@
+//go:build unix && !aix && !illumos && !solaris
+
+package runner
+
+import (
+	"os"
+	"syscall"
+)
+
+// mkfifo creates a named pipe.
+func mkfifo(path string) error {
+	return syscall.Mkfifo(path, 0600)
+}
+
+// unblockFIFO releases a writer still waiting for a reader (the AWK
+// exited without opening the pipe) by briefly opening the read end.
+func unblockFIFO(path string) {
+	r, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err == nil {
+		r.Close()
+	}
+}
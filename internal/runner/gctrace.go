@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kolkov/uawk-bench/internal/stats"
+)
+
+// GCStats is the Go garbage collector activity of one run, parsed from
+// GODEBUG=gctrace=1 output.
+type GCStats struct {
+	Count       int           // Completed GC cycles
+	Pause       time.Duration // Total stop-the-world time (sweep and mark termination)
+	HeapGoal    int64         // Largest heap goal in bytes
+	CPUFraction float64       // Fraction of CPU time spent in GC at the last cycle
+}
+
+// GCSummary aggregates GC activity across runs (medians).
+type GCSummary struct {
+	Count       float64
+	Pause       time.Duration
+	HeapGoal    int64
+	CPUFraction float64
+}
+
+// gcLine matches a gctrace line:
+//
+//	gc 7 @0.153s 3%: 0.012+1.2+0.021 ms clock, ..., 4->4->1 MB, 5 MB goal, ...
+var gcLine = regexp.MustCompile(`^gc (\d+) @[0-9.]+s (\d+)%: ([0-9.]+)\+[0-9.]+\+([0-9.]+) ms clock.*?, (\d+) MB goal`)
+
+// parseGCTrace extracts the gctrace lines from stderr. It returns the GC
+// activity (nil if there are no trace lines) and the remaining output.
+func parseGCTrace(stderr string) (*GCStats, string) {
+	var gc *GCStats
+	var rest []string
+	for _, line := range strings.SplitAfter(stderr, "\n") {
+		m := gcLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			if strings.TrimSpace(line) != "" {
+				rest = append(rest, line)
+			}
+			continue
+		}
+		if gc == nil {
+			gc = &GCStats{}
+		}
+		n, _ := strconv.Atoi(m[1])
+		cpu, _ := strconv.ParseFloat(m[2], 64)
+		sweepTerm, _ := strconv.ParseFloat(m[3], 64)
+		markTerm, _ := strconv.ParseFloat(m[4], 64)
+		goal, _ := strconv.ParseInt(m[5], 10, 64)
+
+		gc.Count = max(gc.Count, n)
+		gc.Pause += time.Duration((sweepTerm + markTerm) * float64(time.Millisecond))
+		gc.HeapGoal = max(gc.HeapGoal, goal<<20)
+		gc.CPUFraction = cpu / 100
+	}
+	return gc, strings.Join(rest, "")
+}
+
+// summarizeGC aggregates the GC activity of samples, or returns nil if
+// none was traced.
+func summarizeGC(samples []Sample) *GCSummary {
+	var counts, pauses, goals, fractions []float64
+	for _, s := range samples {
+		if s.GC == nil {
+			continue
+		}
+		counts = append(counts, float64(s.GC.Count))
+		pauses = append(pauses, float64(s.GC.Pause))
+		goals = append(goals, float64(s.GC.HeapGoal))
+		fractions = append(fractions, s.GC.CPUFraction)
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	return &GCSummary{
+		Count:       stats.Median(counts),
+		Pause:       time.Duration(stats.Median(pauses)),
+		HeapGoal:    int64(stats.Median(goals)),
+		CPUFraction: stats.Median(fractions),
+	}
+}
+
+// withGCTrace adds gctrace=1 to the GODEBUG setting of env.
+func withGCTrace(env []string) []string {
+	for i := len(env) - 1; i >= 0; i-- {
+		if v, ok := strings.CutPrefix(env[i], "GODEBUG="); ok {
+			env[i] = "GODEBUG=" + v + ",gctrace=1"
+			return env
+		}
+	}
+	return append(env, "GODEBUG=gctrace=1")
+}
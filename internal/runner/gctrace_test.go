@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseGCTrace(t *testing.T) {
+	const (
+		gc1 = "gc 1 @0.003s 2%: 0.010+0.45+0.003 ms clock, 0.010+0.12/0.20/0.15+0.003 ms cpu, 3->3->0 MB, 4 MB goal, 0 MB stacks, 0 MB globals, 1 P\n"
+		gc2 = "gc 2 @0.021s 5%: 0.5+1.2+0.25 ms clock, 0.5+0.3/1.1/0.9+0.25 ms cpu, 7->8->3 MB, 9 MB goal, 0 MB stacks, 0 MB globals, 4 P\n"
+		gc3 = "gc 3 @0.040s 4%: 0.020+0.3+0.010 ms clock, 0.020+0/0.2/0+0.010 ms cpu, 5->5->2 MB, 6 MB goal, 0 MB stacks, 0 MB globals, 4 P (forced)\n"
+	)
+	tests := []struct {
+		name     string
+		stderr   string
+		want     *GCStats
+		wantRest string
+	}{
+		{"no trace", "awk: division by zero\n", nil, "awk: division by zero\n"},
+		{"empty", "", nil, ""},
+		{"one cycle", gc1, &GCStats{
+			Count:       1,
+			Pause:       13 * time.Microsecond,
+			HeapGoal:    4 << 20,
+			CPUFraction: 0.02,
+		}, ""},
+		{"cycles and errors", gc1 + "awk: warning\n" + gc2 + gc3 + "exit\n", &GCStats{
+			Count:       3,
+			Pause:       793 * time.Microsecond,
+			HeapGoal:    9 << 20,
+			CPUFraction: 0.04,
+		}, "awk: warning\nexit\n"},
+		{"no trailing newline", "oops\n" + gc1[:len(gc1)-1], &GCStats{
+			Count:       1,
+			Pause:       13 * time.Microsecond,
+			HeapGoal:    4 << 20,
+			CPUFraction: 0.02,
+		}, "oops\n"},
+		{"not a trace line", "gc 1 is fine\n", nil, "gc 1 is fine\n"},
+	}
+	for _, tt := range tests {
+		got, rest := parseGCTrace(tt.stderr)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseGCTrace = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if rest != tt.wantRest {
+			t.Errorf("%s: rest = %q, want %q", tt.name, rest, tt.wantRest)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// InputMode controls how the input file is delivered to the AWK.
+type InputMode string
+
+const (
+	// InputFile passes the file name as an argument.
+	InputFile InputMode = "file"
+	// InputStdin opens the file as the child's stdin (a regular file
+	// descriptor: seekable, mmap-able).
+	InputStdin InputMode = "stdin"
+	// InputPipe feeds stdin through a pipe written by the harness, like
+	// "producer | awk".
+	InputPipe InputMode = "pipe"
+	// InputFIFO passes the path of a named pipe the harness writes to.
+	InputFIFO InputMode = "fifo"
+	// InputList writes the input paths to a list file that a prologue
+	// program appends to ARGV, for file sets beyond ARG_MAX.
+	InputList InputMode = "list"
+)
+
+// listEnv names the environment variable holding the path of the list
+// file. Unlike -v assignments, ENVIRON values skip escape processing.
+const listEnv = "AWKBENCH_ARGLIST"
+
+// listPrologue reads the file list into ARGV. It runs as an extra -f
+// program ahead of the benchmarked one, so ARGV is complete by the time
+// the program's own BEGIN actions run.
+const listPrologue = `BEGIN {
+	while ((getline _awkbench_arg < ENVIRON["` + listEnv + `"]) > 0)
+		ARGV[ARGC++] = _awkbench_arg
+	close(ENVIRON["` + listEnv + `"])
+}
+`
+
+// ParseInputModes parses a comma-separated list of input modes.
+func ParseInputModes(s string) ([]InputMode, error) {
+	var modes []InputMode
+	for _, name := range strings.Split(s, ",") {
+		switch m := InputMode(strings.TrimSpace(name)); m {
+		case InputFile, InputStdin, InputPipe, InputFIFO, InputList:
+			modes = append(modes, m)
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown input mode %q (use file, stdin, pipe, fifo, list)", name)
+		}
+	}
+	return modes, nil
+}
+
+// attachInput configures cmd to receive the inputs in the given mode.
+// Several inputs are passed as several operands (file, fifo), in a list
+// file (list) or concatenated (pipe); stdin takes a single file.
+// The returned function releases resources after the process exited.
+func attachInput(cmd *exec.Cmd, inputs []string, mode InputMode) (func(), error) {
+	if len(inputs) == 0 {
+		return func() {}, nil
+	}
+
+	switch mode {
+	case InputStdin:
+		if len(inputs) > 1 {
+			return nil, fmt.Errorf("stdin input mode takes one file, got %d", len(inputs))
+		}
+		f, err := os.Open(inputs[0])
+		if err != nil {
+			return nil, err
+		}
+		cmd.Stdin = f
+		return func() { f.Close() }, nil
+
+	case InputPipe:
+		files := make([]*os.File, 0, len(inputs))
+		readers := make([]io.Reader, 0, len(inputs))
+		closeAll := func() {
+			for _, f := range files {
+				f.Close()
+			}
+		}
+		for _, input := range inputs {
+			f, err := os.Open(input)
+			if err != nil {
+				closeAll()
+				return nil, err
+			}
+			files = append(files, f)
+			readers = append(readers, f)
+		}
+		// The harness owns the pipe, so exec.Cmd.Wait does not wait for
+		// the copy (see outputPipe)
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			io.Copy(pw, io.MultiReader(readers...))
+			pw.Close()
+		}()
+		cmd.Stdin = pr
+		return func() {
+			pr.Close()
+			pw.Close() // Unblocks the copy if processes holding the pipe stopped reading
+			<-done
+			closeAll()
+		}, nil
+
+	case InputFIFO:
+		dir, err := os.MkdirTemp("", fifoDirPattern)
+		if err != nil {
+			return nil, err
+		}
+		fifos := make([]string, len(inputs))
+		for i := range inputs {
+			fifos[i] = fifoName(dir, i)
+			if err := mkfifo(fifos[i]); err != nil {
+				os.RemoveAll(dir)
+				return nil, err
+			}
+		}
+		var wg sync.WaitGroup
+		for i, input := range inputs {
+			wg.Add(1)
+			go func(fifo, input string) {
+				defer wg.Done()
+				writeFIFO(fifo, input)
+			}(fifos[i], input)
+		}
+		cmd.Args = append(cmd.Args, fifos...)
+		return func() {
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+			// Writers of FIFOs the AWK never opened are still blocked, or
+			// are yet to block if the AWK exited (or failed to start)
+			// before they ran, so keep unblocking until all have returned
+			for {
+				for _, fifo := range fifos {
+					unblockFIFO(fifo)
+				}
+				select {
+				case <-done:
+					os.RemoveAll(dir)
+					return
+				case <-time.After(time.Millisecond):
+				}
+			}
+		}, nil
+
+	case InputList:
+		n := len(cmd.Args)
+		if n < 3 || cmd.Args[n-2] != "-f" {
+			return nil, fmt.Errorf("list input mode needs a program file")
+		}
+		dir, err := os.MkdirTemp("", "awkbench-list-")
+		if err != nil {
+			return nil, err
+		}
+		list := filepath.Join(dir, "inputs")
+		prologue := filepath.Join(dir, "prologue.awk")
+		err = os.WriteFile(list, []byte(strings.Join(inputs, "\n")+"\n"), 0600)
+		if err == nil {
+			err = os.WriteFile(prologue, []byte(listPrologue), 0600)
+		}
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, err
+		}
+		// The program is the trailing "-f file"; the prologue goes first
+		program := cmd.Args[n-2:]
+		cmd.Args = append(cmd.Args[:n-2:n-2], "-f", prologue)
+		cmd.Args = append(cmd.Args, program...)
+		cmd.Env = append(cmd.Env, listEnv+"="+list)
+		return func() { os.RemoveAll(dir) }, nil
+
+	default:
+		cmd.Args = append(cmd.Args, inputs...)
+		return func() {}, nil
+	}
+}
+
+// fifoDirPattern is the os.MkdirTemp pattern of FIFO directories.
+const fifoDirPattern = "awkbench-fifo-"
+
+// fifoName returns the path of the FIFO for input i in dir.
+func fifoName(dir string, i int) string {
+	return filepath.Join(dir, fmt.Sprintf("input%d", i))
+}
+
+// writeFIFO copies the input into a named pipe. Opening the pipe blocks
+// until the AWK opens it for reading.
+func writeFIFO(fifo, input string) {
+	w, err := os.OpenFile(fifo, os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	defer w.Close()
+
+	f, err := os.Open(input)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	// Write errors (EPIPE when the AWK exits early) surface as the
+	// AWK's own exit status or output mismatch.
+	io.Copy(w, f)
+}
@@ -0,0 +1,44 @@
+//go:build linux
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// groupMembers lists the live processes in the group pgid as
+// "pid name", from /proc/<pid>/stat.
+func groupMembers(pgid int) []string {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+
+	var members []string
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			continue // Exited meanwhile
+		}
+
+		// pid (comm) state ppid pgrp ...; comm may contain spaces
+		stat := string(data)
+		lp, rp := strings.IndexByte(stat, '('), strings.LastIndexByte(stat, ')')
+		if lp < 0 || rp < lp {
+			continue
+		}
+		fields := strings.Fields(stat[rp+1:])
+		if len(fields) < 3 || fields[0] == "Z" || fields[2] != strconv.Itoa(pgid) {
+			continue // Zombies are already dead
+		}
+		members = append(members, fmt.Sprintf("%d %s", pid, stat[lp+1:rp]))
+	}
+	return members
+}
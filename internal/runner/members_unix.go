@@ -0,0 +1,14 @@
+//go:build unix && !linux
+
+package runner
+
+import "syscall"
+
+// groupMembers reports whether the group led by pid still has members.
+// Their names are not available without /proc.
+func groupMembers(pgid int) []string {
+	if syscall.Kill(-pgid, 0) != nil {
+		return nil
+	}
+	return []string{"(unknown)"}
+}
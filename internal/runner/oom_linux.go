@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// oomKills returns a count of OOM kills that includes the AWK's: the
+// oom_kill counter of the harness's cgroup (v2 memory.events, which
+// counts descendants and thus the AWK), or else the system-wide one
+// from /proc/vmstat.
+func oomKills() (int64, bool) {
+	if dir, ok := cgroupDir(); ok {
+		if n, ok := readCounter(filepath.Join(dir, "memory.events"), "oom_kill"); ok {
+			return n, true
+		}
+	}
+	return readCounter("/proc/vmstat", "oom_kill")
+}
+
+// cgroupDir returns the cgroup v2 directory of the harness.
+func cgroupDir() (string, bool) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			return filepath.Join("/sys/fs/cgroup", path), true
+		}
+	}
+	return "", false
+}
+
+// readCounter reads a "name value" line from a flat keyed file.
+func readCounter(path, name string) (int64, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		key, value, _ := strings.Cut(sc.Text(), " ")
+		if key == name {
+			n, err := strconv.ParseInt(value, 10, 64)
+			return n, err == nil
+		}
+	}
+	return 0, false
+}
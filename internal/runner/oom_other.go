@@ -0,0 +1,8 @@
+//go:build !linux
+
+package runner
+
+// oomKills reports no count: OOM kills are only counted on Linux.
+func oomKills() (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// outputPipe carries one output stream of the AWK to a writer. Given a
+// plain io.Writer, exec.Cmd copies the output itself and Wait returns
+// only once every process holding the pipe has closed it, so processes
+// left running by the AWK would be timed as part of the run. With a pipe
+// owned by the harness, Wait returns when the AWK itself exits.
+type outputPipe struct {
+	r, w *os.File
+	done chan struct{}
+}
+
+// newOutputPipe starts copying a new pipe to dst. The child gets p.w.
+func newOutputPipe(dst io.Writer) (*outputPipe, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	p := &outputPipe{r: r, w: w, done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		io.Copy(dst, r)
+	}()
+	return p, nil
+}
+
+// newOutputPipes creates an outputPipe for each writer.
+func newOutputPipes(dsts ...io.Writer) ([]*outputPipe, error) {
+	pipes := make([]*outputPipe, 0, len(dsts))
+	for _, dst := range dsts {
+		p, err := newOutputPipe(dst)
+		if err != nil {
+			discardOutput(pipes...)
+			return nil, err
+		}
+		pipes = append(pipes, p)
+	}
+	return pipes, nil
+}
+
+// started closes the harness's copy of the write end once the child has
+// its own, so the copy ends when the last process holding it exits.
+func (p *outputPipe) started() {
+	p.w.Close()
+}
+
+// drainOutput waits up to grace for the processes still holding the
+// pipes to close them, then closes the pipes, dropping later output.
+func drainOutput(grace time.Duration, pipes ...*outputPipe) {
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	expired := false
+	for _, p := range pipes {
+		if !expired {
+			select {
+			case <-p.done:
+			case <-timer.C:
+				expired = true
+			}
+		}
+		p.r.Close()
+		<-p.done
+	}
+}
+
+// discardOutput closes pipes that were never handed to a child.
+func discardOutput(pipes ...*outputPipe) {
+	for _, p := range pipes {
+		p.started()
+	}
+	drainOutput(0, pipes...)
+}
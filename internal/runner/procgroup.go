@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// processTree stops an AWK together with everything it started
+// (system(), "cmd" | getline, print | "cmd"). The AWK leads its own
+// process group; on timeout or interrupt the group gets SIGTERM and,
+// after a grace period, SIGKILL.
+type processTree struct {
+	grace   time.Duration
+	stopped atomic.Bool // The harness signalled the group
+
+	mu   sync.Mutex
+	kill *time.Timer
+}
+
+// terminate is the exec.Cmd.Cancel hook for the group led by pid.
+func (t *processTree) terminate(pid int) error {
+	t.stopped.Store(true)
+	err := signalGroup(pid, sigTerm)
+
+	t.mu.Lock()
+	t.kill = time.AfterFunc(t.grace, func() { signalGroup(pid, sigKill) })
+	t.mu.Unlock()
+	return err
+}
+
+// reap cancels a pending SIGKILL once the AWK has been waited for, then
+// kills and returns the group members still running ("pid name").
+func (t *processTree) reap(pid int) []string {
+	t.mu.Lock()
+	if t.kill != nil {
+		t.kill.Stop()
+	}
+	t.mu.Unlock()
+
+	leftovers := groupMembers(pid)
+	if len(leftovers) > 0 {
+		signalGroup(pid, sigKill)
+	}
+	return leftovers
+}
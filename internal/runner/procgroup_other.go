@@ -0,0 +1,35 @@
+//go:build !unix
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+)
+
+// signal stands in for syscall.Signal, which not every platform has
+// (plan9). Only sigKill has an effect.
+type signal int
+
+const (
+	sigTerm signal = iota + 1
+	sigKill
+)
+
+// newProcessGroup does nothing: process groups are not supported, so
+// only the AWK itself is stopped.
+func newProcessGroup(cmd *exec.Cmd) {}
+
+// signalGroup kills the process; there are no signals to send.
+func signalGroup(pid int, sig signal) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
+
+// groupMembers returns nil: leftover processes cannot be found.
+func groupMembers(pgid int) []string {
+	return nil
+}
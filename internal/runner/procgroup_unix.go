@@ -0,0 +1,29 @@
+//go:build unix
+
+package runner
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// signal is a signal sent to a process group or one that killed the AWK.
+type signal = syscall.Signal
+
+const (
+	sigTerm = syscall.SIGTERM
+	sigKill = syscall.SIGKILL
+)
+
+// newProcessGroup makes cmd the leader of a new process group.
+func newProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// signalGroup sends sig to every process in the group led by pid.
+func signalGroup(pid int, sig signal) error {
+	return syscall.Kill(-pid, sig)
+}
@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kolkov/uawk-bench/internal/profile"
+)
+
+// ProfileKind is a kind of profile.
+type ProfileKind string
+
+const (
+	ProfileCPU    ProfileKind = "cpu"
+	ProfileMemory ProfileKind = "mem"
+)
+
+// ParseProfileKinds parses a comma-separated list of profile kinds.
+func ParseProfileKinds(s string) ([]ProfileKind, error) {
+	var kinds []ProfileKind
+	for _, part := range strings.Split(s, ",") {
+		switch k := ProfileKind(strings.TrimSpace(part)); k {
+		case ProfileCPU, ProfileMemory:
+			kinds = append(kinds, k)
+		case "":
+		default:
+			return nil, fmt.Errorf("invalid profile kind: %s (use cpu, mem)", k)
+		}
+	}
+	return kinds, nil
+}
+
+// Profiler is an AWK's recipe for writing pprof profiles: the arguments
+// that enable each kind, with "{}" standing for the output file.
+type Profiler struct {
+	CPU    []string // e.g. -cpuprofile {}
+	Memory []string // e.g. -memprofile {}
+}
+
+// GoProfiler is the profiling recipe of uawk and goawk.
+var GoProfiler = Profiler{
+	CPU:    []string{"-cpuprofile", "{}"},
+	Memory: []string{"-memprofile", "{}"},
+}
+
+// Supports reports whether the recipe covers a profile kind.
+func (p Profiler) Supports(kind ProfileKind) bool {
+	return p.args(kind, "") != nil
+}
+
+// args returns the arguments enabling a profile of the given kind
+// written to path, or nil if the AWK cannot write it.
+func (p Profiler) args(kind ProfileKind, path string) []string {
+	recipe := p.CPU
+	if kind == ProfileMemory {
+		recipe = p.Memory
+	}
+	return expandPath(recipe, path)
+}
+
+// expandPath replaces "{}" in args with path.
+func expandPath(args []string, path string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	expanded := make([]string, len(args))
+	for i, a := range args {
+		expanded[i] = strings.ReplaceAll(a, "{}", path)
+	}
+	return expanded
+}
+
+// ErrNoProfiler means an AWK has no recipe for a profile kind and there
+// is no wrapper for it.
+var ErrNoProfiler = errors.New("no profiler for this AWK")
+
+// Profile describes a profile captured for a cell.
+type Profile struct {
+	Kind  ProfileKind
+	Path  string
+	Type  string          // Sample type summarized, e.g. "cpu" (pprof only)
+	Unit  string          // Unit of Total and Top values, e.g. "nanoseconds"
+	Total int64           // Sum over all samples
+	Top   []profile.Entry // Functions with the highest flat value
+	Error string          // Why capturing or reading the profile failed
+}
+
+// ProfileCell runs a cell once, untimed, writing a profile of the given
+// kind to path. AWKs with a Profiler recipe write pprof profiles, which
+// are summarized into the top ProfileTop functions; others run under
+// ProfileWrapper (CPU only), whose output is kept as is.
+func (r *Runner) ProfileCell(ctx context.Context, c Cell, kind ProfileKind, path string) Profile {
+	summary := Profile{Kind: kind, Path: path}
+	awk := withLocale(c.AWK, c.Locale)
+	pprof := true
+	if args := awk.Profiler.args(kind, path); args != nil {
+		awk.Args = append(args, awk.Args...)
+	} else if wrapper := expandPath(r.ProfileWrapper, path); wrapper != nil && kind == ProfileCPU {
+		awk.Args = append(append(wrapper[1:], awk.Command), awk.Args...)
+		awk.Command = wrapper[0]
+		pprof = false
+	} else {
+		summary.Error = ErrNoProfiler.Error()
+		return summary
+	}
+
+	os.Remove(path) // Do not mistake a stale profile for a new one
+	res := r.RunMode(ctx, awk, c.Program, c.Inputs, r.inputMode(c))
+	if res.Error != nil {
+		summary.Error = res.Error.Error()
+		return summary
+	}
+	if _, err := os.Stat(path); err != nil {
+		summary.Error = fmt.Sprintf("no profile written: %v", err)
+		return summary
+	}
+	if !pprof {
+		return summary
+	}
+
+	p, err := profile.ParseFile(path)
+	if err != nil {
+		summary.Error = err.Error()
+		return summary
+	}
+	if n := len(p.SampleTypes); n > 0 {
+		i := n - 1 // pprof's default: cpu time, in-use bytes
+		summary.Type, summary.Unit = p.SampleTypes[i].Type, p.SampleTypes[i].Unit
+		summary.Total = p.Total(i)
+		summary.Top = p.Top(i, r.ProfileTop)
+	}
+	return summary
+}
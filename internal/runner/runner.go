@@ -4,40 +4,96 @@ package runner
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/kolkov/uawk-bench/internal/stats"
 )
 
 // AWK represents an AWK implementation to benchmark.
 type AWK struct {
 	Name    string   // Display name (e.g., "uawk", "goawk")
 	Command string   // Executable name or path
-	Args    []string // Additional arguments (e.g., ["-b"] for gawk)
+	Args    []string // Additional arguments (e.g., ["--no-posix"] for uawk)
+	Env     []string // Environment variables ("KEY=value"), override the runner's
+	CPUs    []int    // CPUs to pin the process to (overrides Runner.CPUs)
+
+	GoRuntime bool     // Written in Go: GC activity can be traced (see Runner.GCTrace)
+	Profiler  Profiler // How to make it write pprof profiles (see Runner.ProfileCell)
+
+	Params Params // Sweep parameters and levels (see package sweep)
 }
 
+// Params maps sweep dimensions to the levels of an AWK variant.
+type Params map[string]string
+
 // Result holds benchmark results for a single run.
 type Result struct {
 	AWK      string        // AWK implementation name
 	Program  string        // AWK program name
 	Duration time.Duration // Execution time
-	Output   string        // Program output (for verification)
+	Usage    Usage         // Resource usage of the AWK process
+	ExitCode int           // Exit status (-1 if killed by a signal or not started)
+	Status   Status        // How the run ended
+	Stderr   string        // Excerpt of the error output of a failed run
+	Leftover []string      // Processes of the AWK's group still running after it exited ("pid name"), killed
+	Counters *Counters     // Performance counters (nil = not collected)
+	Series   []Point       // RSS and I/O sampled during the run
+	GC       *GCStats      // Go GC activity (nil = not traced)
+	Output   string        // Program output (prefix, see Runner.OutputLimit)
+	Digest   Digest        // Size, line count and hash of the full output
 	Error    error         // Error if execution failed
 }
 
 // BenchmarkResult holds aggregated results for multiple runs.
 type BenchmarkResult struct {
-	AWK       string
-	Program   string
-	Runs      int
-	Min       time.Duration
-	Max       time.Duration
-	Mean      time.Duration
-	Median    time.Duration
-	StdDev    time.Duration
-	Throughput float64 // MB/s based on input size
+	AWK         string
+	Program     string
+	Runs        int
+	Min         time.Duration
+	Max         time.Duration
+	Mean        time.Duration
+	Median      time.Duration
+	MedianLow   time.Duration // Bootstrap 95% CI of the median
+	MedianHigh  time.Duration
+	StdDev      time.Duration
+	TrimmedMean time.Duration // Mean without the fastest and slowest 10%
+	IQR         time.Duration // Interquartile range
+	Throughput  float64       // MB/s based on input size
+	Usage       UsageStats    // CPU time, peak RSS, page faults, context switches
+	Counters    *CounterStats // Performance counters (nil = not collected)
+	Memory      *MemoryStats  // Sampled RSS and I/O (nil = not sampled)
+	ReadRate    *ReadStats    // Read throughput early vs late in runs (nil = not sampled)
+	GC          *GCSummary    // Go GC activity (nil = not traced)
+	Profiles    []Profile     // Profiles captured after timing (awkbench profile)
+	CPUs        []int         // CPUs the AWK was pinned to (nil = not pinned)
+	Nice        int           // Nice value of the AWK process
+	Schedule    string        // Run order used (sequential, roundrobin, shuffle)
+	InputMode   string        // Input delivery (file, stdin, pipe, fifo)
+	Layout      string        // Input layout (e.g. "single", "4 shards")
+	Locale      string        // LC_ALL of the AWK process
+	Params      Params        // Sweep parameters of the AWK variant
+	CacheMode   string        // Page cache mode (default, hot, cold)
+	Residency   float64       // Mean fraction of the input cached before runs (-1 = not measured)
+	Seed        int64         // Seed of the shuffled schedule
+	Precision   float64       // Relative 95% CI half-width of the median (0 if n < 8)
+	Samples     []Sample      // Every measured run, in run order
+	Warmups     []Sample      // Warmup runs (not used for statistics)
+	Outliers    []int         // Indices into Samples classified as outliers
+	Robust      bool          // Statistics exclude the outliers
+	Startup     time.Duration // Median startup cost of the AWK (0 = not subtracted)
+	Net         time.Duration // Median minus Startup
+	NetError    time.Duration // 95% uncertainty of Net
+	Failures    []Sample      // Failed runs (see Runner.OnFailure)
+	Failed      bool          // Output did not match the reference or runs failed; not ranked
+	FailReason  string        // Why verification or the cell failed
 }
 
 // Runner executes AWK benchmarks.
@@ -46,24 +102,95 @@ type Runner struct {
 	Timeout time.Duration
 	Warmup  int // Number of warmup runs
 	Runs    int // Number of measured runs
+
+	Schedule Schedule // Order of runs across cells (see BenchmarkCells)
+	Seed     int64    // Seed for the Shuffle schedule
+
+	// Adaptive run count: keep sampling until the relative 95% CI
+	// half-width of the median is at most TargetCI (0 = use Runs).
+	TargetCI float64
+	MinRuns  int           // Minimum samples in adaptive mode (0 = Runs)
+	MaxRuns  int           // Maximum samples in adaptive mode (0 = unlimited)
+	Budget   time.Duration // Measured time per cell in adaptive mode (0 = unlimited)
+
+	// CPU pool for pinning children (Linux). Each AWK is pinned to as many
+	// CPUs from the start of the pool as its Parallelism; nil = no pinning.
+	CPUs []int
+	Nice int // Nice value for children (negative needs privileges)
+
+	// Collect performance counters of every run (Linux, perf_event_open;
+	// see ProbeCounters). Counters the kernel refuses are left out.
+	Counters bool
+
+	// Poll the RSS and I/O counters of every AWK process at this interval
+	// from /proc (Linux; 0 = off). The AWK's children are not included.
+	SampleInterval time.Duration
+
+	// Flag cells whose read throughput in the last quarter of reading is
+	// lower than in the first quarter by more than this fraction (sampled
+	// runs only; 0 = never).
+	SlowdownThreshold float64
+
+	// Run AWKs with GoRuntime set under GODEBUG=gctrace=1 and parse their
+	// GC activity; the trace is removed from the error output.
+	GCTrace bool
+
+	// Profiling (ProfileCell): command prefix for AWKs without a Profiler
+	// recipe, "{}" standing for the output file (e.g. perf record -o {} --),
+	// and the number of functions in profile summaries.
+	ProfileWrapper []string
+	ProfileTop     int
+
+	CacheMode CacheMode // Page cache state of the input before each run
+	InputMode InputMode // Default input delivery (Cell.InputMode overrides)
+
+	OutlierMethod   stats.OutlierMethod // How outliers are classified
+	ExcludeOutliers bool                // Compute statistics (timing and resources) without outliers
+
+	OutputLimit int // Bytes of output kept in Result.Output for diagnostics
+
+	// Environment of AWK processes: PATH, HOME and temp dirs from the
+	// harness (or all of it with InheritEnv), LC_ALL=Locale, AWK.Env.
+	InheritEnv bool
+	Locale     string // Default LC_ALL (Cell.Locale overrides; empty = unset)
+
+	OnFailure FailurePolicy // What to do when a run fails
+	Retries   int           // Repeats of a failed run with FailRetry
+
+	// Time between SIGTERM and SIGKILL to the AWK's process group on
+	// timeout or interrupt; also how long to wait for the output pipes
+	// after the AWK exited (processes it started may hold them open).
+	Grace time.Duration
 }
 
 // NewRunner creates a runner with default settings.
 func NewRunner() *Runner {
 	return &Runner{
-		Timeout: 5 * time.Minute,
-		Warmup:  1,
-		Runs:    5,
+		Timeout:   5 * time.Minute,
+		InputMode: InputFile,
+		Warmup:    1,
+		Runs:      5,
+
+		OutlierMethod: stats.Tukey,
+		OutputLimit:   4096,
+		Locale:        "C",
+
+		OnFailure: FailAbort,
+		Retries:   2,
+		Grace:     2 * time.Second,
+
+		SlowdownThreshold: 0.2,
+		ProfileTop:        10,
 	}
 }
 
 // DefaultAWKs returns the standard set of AWK implementations to test.
 func DefaultAWKs() []AWK {
 	return []AWK{
-		{Name: "uawk", Command: "uawk"},                                    // POSIX mode (default)
-		{Name: "uawk-fast", Command: "uawk", Args: []string{"--no-posix"}}, // Fast mode (no Longest)
-		{Name: "goawk", Command: "goawk"},
-		{Name: "gawk", Command: "gawk", Args: []string{"-b"}}, // -b disables multibyte
+		{Name: "uawk", Command: "uawk", GoRuntime: true, Profiler: GoProfiler},                                    // POSIX mode (default)
+		{Name: "uawk-fast", Command: "uawk", Args: []string{"--no-posix"}, GoRuntime: true, Profiler: GoProfiler}, // Fast mode (no Longest)
+		{Name: "goawk", Command: "goawk", GoRuntime: true, Profiler: GoProfiler},
+		{Name: "gawk", Command: "gawk"}, // Byte or multibyte mode follows Runner.Locale
 		{Name: "mawk", Command: "mawk"},
 	}
 }
@@ -127,65 +254,127 @@ func FindAvailable(awks []AWK) []AWK {
 	return available
 }
 
-// Run executes an AWK program with the given input file.
-func (r *Runner) Run(ctx context.Context, awk AWK, programFile, inputFile string) Result {
-	args := append([]string{}, awk.Args...)
-	args = append(args, "-f", programFile, inputFile)
-
-	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, awk.Command, args...)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	start := time.Now()
-	err := cmd.Run()
-	duration := time.Since(start)
+// Run executes an AWK program with the given input files, delivered as
+// configured by Runner.InputMode. Output is streamed through a Sink;
+// Result.Output keeps at most OutputLimit bytes of it.
+func (r *Runner) Run(ctx context.Context, awk AWK, programFile string, inputFiles ...string) Result {
+	return r.RunMode(ctx, awk, programFile, inputFiles, r.InputMode)
+}
 
-	if err != nil {
-		return Result{
-			AWK:      awk.Name,
-			Program:  programFile,
-			Duration: duration,
-			Error:    fmt.Errorf("%w: %s", err, stderr.String()),
-		}
-	}
+// RunMode is like Run with an explicit input delivery mode.
+func (r *Runner) RunMode(ctx context.Context, awk AWK, programFile string, inputFiles []string, mode InputMode) Result {
+	args := append([]string{}, awk.Args...)
+	args = append(args, "-f", programFile)
+	return r.exec(ctx, awk, programFile, args, inputFiles, mode, r.OutputLimit)
+}
 
-	return Result{
-		AWK:      awk.Name,
-		Program:  programFile,
-		Duration: duration,
-		Output:   stdout.String(),
-	}
+// RunCapture is like Run but keeps the complete output.
+// It is meant for verification runs, not for timing.
+func (r *Runner) RunCapture(ctx context.Context, awk AWK, programFile string, inputFiles ...string) Result {
+	args := append([]string{}, awk.Args...)
+	args = append(args, "-f", programFile)
+	return r.exec(ctx, awk, programFile, args, inputFiles, r.InputMode, -1)
 }
 
 // RunInline executes an inline AWK program.
-func (r *Runner) RunInline(ctx context.Context, awk AWK, program, inputFile string) Result {
+func (r *Runner) RunInline(ctx context.Context, awk AWK, program string, inputFiles ...string) Result {
 	args := append([]string{}, awk.Args...)
-	args = append(args, program, inputFile)
+	args = append(args, program)
+	return r.exec(ctx, awk, program, args, inputFiles, r.InputMode, r.OutputLimit)
+}
 
+// exec runs the AWK command with the inputs delivered in the given mode,
+// keeping up to limit bytes of its output.
+func (r *Runner) exec(ctx context.Context, awk AWK, program string, args []string, inputs []string, mode InputMode, limit int) Result {
 	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, awk.Command, args...)
+	cmd.Env = r.environ(awk)
+	tree := &processTree{grace: r.Grace}
+	newProcessGroup(cmd)
+	cmd.Cancel = func() error { return tree.terminate(cmd.Process.Pid) }
+	cmd.WaitDelay = r.Grace
+
+	stdout := NewSink(limit)
+	var stderr bytes.Buffer
+	pipes, err := newOutputPipes(stdout, &stderr)
+	if err != nil {
+		return Result{AWK: awk.Name, Program: program, ExitCode: -1, Status: Status{Kind: StatusError}, Error: err}
+	}
+	cmd.Stdout = pipes[0].w
+	cmd.Stderr = pipes[1].w
+
+	release, err := attachInput(cmd, inputs, mode)
+	if err != nil {
+		discardOutput(pipes...)
+		return Result{AWK: awk.Name, Program: program, ExitCode: -1, Status: Status{Kind: StatusError}, Error: err}
+	}
+	defer release()
+
+	var perf *counterSet
+	if r.Counters {
+		perf = newCounterSet()
+		defer perf.close()
+	}
+	oomBefore, oomCounted := oomKills()
+	start, err := startProcess(cmd, r.cpuSet(awk), r.Nice, perf)
+	for _, p := range pipes {
+		p.started()
+	}
+	var duration time.Duration
+	var mon *sampler
+	if err == nil {
+		if r.SampleInterval > 0 {
+			mon = startSampler(cmd.Process.Pid, start, r.SampleInterval)
+		}
+		err = cmd.Wait()
+		duration = time.Since(start) // The AWK exited, whoever still holds its output
+	}
+	series := mon.stop()
+	counters := perf.read()
+
+	// Processes the AWK left running may still write; give them the grace
+	// period, then kill them
+	drainOutput(r.Grace, pipes...)
+	var leftover []string
+	if cmd.Process != nil {
+		leftover = tree.reap(cmd.Process.Pid)
+	}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	usage := processUsage(cmd.ProcessState)
+	exitCode := -1
+	if cmd.ProcessState != nil {
+		exitCode = cmd.ProcessState.ExitCode()
+	}
 
-	start := time.Now()
-	err := cmd.Run()
-	duration := time.Since(start)
+	errOutput := stderr.String()
+	var gc *GCStats
+	if r.traceGC(awk) {
+		gc, errOutput = parseGCTrace(errOutput)
+	}
 
 	if err != nil {
+		oomAfter, ok := oomKills()
+		oom := oomCounted && ok && oomAfter > oomBefore
+		status := classify(cmd.ProcessState, tree.stopped.Load(), ctx.Err(), oom)
+		err = fmt.Errorf("%s: %w", status, err)
+		if msg := strings.TrimSpace(errOutput); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
 		return Result{
 			AWK:      awk.Name,
 			Program:  program,
 			Duration: duration,
-			Error:    fmt.Errorf("%w: %s", err, stderr.String()),
+			Usage:    usage,
+			ExitCode: exitCode,
+			Status:   status,
+			Stderr:   excerpt(errOutput),
+			Leftover: leftover,
+			Counters: counters,
+			Series:   series,
+			GC:       gc,
+			Error:    err,
 		}
 	}
 
@@ -193,32 +382,54 @@ func (r *Runner) RunInline(ctx context.Context, awk AWK, program, inputFile stri
 		AWK:      awk.Name,
 		Program:  program,
 		Duration: duration,
-		Output:   stdout.String(),
+		Usage:    usage,
+		ExitCode: exitCode,
+		Status:   Status{Kind: StatusOK},
+		Leftover: leftover,
+		Counters: counters,
+		Series:   series,
+		GC:       gc,
+		Output:   stdout.Output(),
+		Digest:   stdout.Digest(),
 	}
 }
 
-// Benchmark runs multiple iterations and returns aggregated results.
-func (r *Runner) Benchmark(ctx context.Context, awk AWK, programFile, inputFile string, inputSize int64) (*BenchmarkResult, error) {
-	// Warmup runs
-	for i := 0; i < r.Warmup; i++ {
-		result := r.Run(ctx, awk, programFile, inputFile)
-		if result.Error != nil {
-			return nil, result.Error
-		}
+// classify determines how a failed run ended. A run the harness
+// stopped ended because of its timeout or an interrupt (cause), however
+// the AWK died. Any other SIGKILL is put down to the OOM killer only if
+// an OOM kill was counted during the run (oom).
+func classify(ps *os.ProcessState, stopped bool, cause error, oom bool) Status {
+	switch {
+	case stopped && errors.Is(cause, context.Canceled):
+		return Status{Kind: StatusCanceled}
+	case stopped:
+		return Status{Kind: StatusTimeout}
+	case ps == nil && errors.Is(cause, context.Canceled):
+		return Status{Kind: StatusCanceled}
+	case ps == nil:
+		return Status{Kind: StatusError}
 	}
-
-	// Measured runs
-	durations := make([]time.Duration, r.Runs)
-	for i := 0; i < r.Runs; i++ {
-		result := r.Run(ctx, awk, programFile, inputFile)
-		if result.Error != nil {
-			return nil, result.Error
+	if sig, ok := exitSignal(ps); ok {
+		switch {
+		case sig == sigKill && oom:
+			return Status{Kind: StatusOOM, Code: int(sig)}
+		case sig == sigKill:
+			return Status{Kind: StatusKilled, Code: int(sig)}
 		}
-		durations[i] = result.Duration
+		return Status{Kind: StatusSignal, Code: int(sig)}
 	}
+	return Status{Kind: StatusExit, Code: ps.ExitCode()}
+}
 
-	// Calculate statistics
-	return calculateStats(awk.Name, programFile, durations, inputSize), nil
+// Benchmark runs multiple iterations and returns aggregated results.
+func (r *Runner) Benchmark(ctx context.Context, awk AWK, programFile, inputFile string, inputSize int64) (*BenchmarkResult, error) {
+	results, errs := r.BenchmarkCells(ctx, []Cell{{
+		AWK:       awk,
+		Program:   programFile,
+		Inputs:    []string{inputFile},
+		InputSize: inputSize,
+	}})
+	return results[0], errs[0]
 }
 
 func calculateStats(awkName, program string, durations []time.Duration, inputSize int64) *BenchmarkResult {
@@ -263,7 +474,7 @@ func calculateStats(awkName, program string, durations []time.Duration, inputSiz
 		variance += diff * diff
 	}
 	variance /= float64(n)
-	stdDev := time.Duration(sqrt(variance))
+	stdDev := time.Duration(math.Sqrt(variance))
 
 	// Throughput (MB/s)
 	var throughput float64
@@ -271,17 +482,52 @@ func calculateStats(awkName, program string, durations []time.Duration, inputSiz
 		throughput = float64(inputSize) / (1024 * 1024) / mean.Seconds()
 	}
 
+	// Bootstrap CI for the median (fixed seed keeps reports reproducible)
+	seconds := Seconds(durations)
+	lo, hi := stats.Bootstrap(seconds, stats.Median, stats.DefaultIterations, 0.95, rand.New(rand.NewSource(1)))
+
 	return &BenchmarkResult{
-		AWK:        awkName,
-		Program:    program,
-		Runs:       n,
-		Min:        min,
-		Max:        max,
-		Mean:       mean,
-		Median:     median,
-		StdDev:     stdDev,
-		Throughput: throughput,
+		AWK:         awkName,
+		Program:     program,
+		Runs:        n,
+		Min:         min,
+		Max:         max,
+		Mean:        mean,
+		Median:      median,
+		MedianLow:   secondsToDuration(lo),
+		MedianHigh:  secondsToDuration(hi),
+		StdDev:      stdDev,
+		TrimmedMean: secondsToDuration(stats.TrimmedMean(seconds, 0.1)),
+		IQR:         secondsToDuration(stats.IQR(seconds)),
+		Throughput:  throughput,
+	}
+}
+
+// Durations returns the durations of all measured samples in run order.
+func (b *BenchmarkResult) Durations() []time.Duration {
+	return sampleDurations(b.Samples)
+}
+
+// Kept returns the durations the statistics were computed from: all
+// samples, or only the non-outliers in robust mode.
+func (b *BenchmarkResult) Kept() []time.Duration {
+	if !b.Robust {
+		return b.Durations()
 	}
+	return sampleDurations(withoutIndices(b.Samples, b.Outliers))
+}
+
+// Seconds converts durations to seconds for statistical functions.
+func Seconds(durations []time.Duration) []float64 {
+	xs := make([]float64, len(durations))
+	for i, d := range durations {
+		xs[i] = d.Seconds()
+	}
+	return xs
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
 }
 
 func sortDurations(d []time.Duration) {
@@ -296,14 +542,3 @@ func sortDurations(d []time.Duration) {
 		d[j+1] = key
 	}
 }
-
-func sqrt(x float64) float64 {
-	if x <= 0 {
-		return 0
-	}
-	z := x / 2
-	for i := 0; i < 10; i++ {
-		z = z - (z*z-x)/(2*z)
-	}
-	return z
-}
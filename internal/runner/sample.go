@@ -0,0 +1,62 @@
+package runner
+
+import "time"
+
+// Sample is the raw measurement of one run, kept so results can be
+// re-analyzed later without re-running the benchmark.
+type Sample struct {
+	Duration  time.Duration // Wall-clock time
+	Usage     Usage         // Resource usage of the AWK process
+	ExitCode  int           // Exit status
+	Status    Status        // How the run ended
+	Stderr    string        // Excerpt of the error output of a failed run
+	Leftover  []string      // Processes left running by the AWK, killed after it exited
+	Counters  *Counters     // Performance counters (nil = not collected)
+	Series    []Point       // RSS and I/O sampled during the run (see Runner.SampleInterval)
+	Timeline  []Rate        // Read throughput between samples, from rchar
+	GC        *GCStats      // Go GC activity (nil = not traced)
+	Error     string        // Error message if the run failed
+	Warmup    bool          // Warmup run (excluded from statistics)
+	Residency float64       // Fraction of the input in the page cache before the run (-1 = not measured)
+}
+
+// newSample records a run result.
+func newSample(result Result, warmup bool) Sample {
+	s := Sample{
+		Duration: result.Duration,
+		Usage:    result.Usage,
+		ExitCode: result.ExitCode,
+		Status:   result.Status,
+		Stderr:   result.Stderr,
+		Leftover: result.Leftover,
+		Counters: result.Counters,
+		Series:   result.Series,
+		Timeline: readTimeline(result.Series),
+		GC:       result.GC,
+		Warmup:   warmup,
+
+		Residency: -1,
+	}
+	if result.Error != nil {
+		s.Error = result.Error.Error()
+	}
+	return s
+}
+
+// sampleDurations returns the wall-clock times of samples.
+func sampleDurations(samples []Sample) []time.Duration {
+	durations := make([]time.Duration, len(samples))
+	for i, s := range samples {
+		durations[i] = s.Duration
+	}
+	return durations
+}
+
+// sampleUsages returns the resource usage of samples.
+func sampleUsages(samples []Sample) []Usage {
+	usages := make([]Usage, len(samples))
+	for i, s := range samples {
+		usages[i] = s.Usage
+	}
+	return usages
+}
@@ -0,0 +1,83 @@
+//go:build linux
+
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sampler polls /proc for the RSS and I/O counters of a running process.
+type sampler struct {
+	stopc  chan struct{}
+	done   chan struct{}
+	series []Point
+}
+
+// startSampler starts polling pid every interval until stop is called.
+// Times are relative to start.
+func startSampler(pid int, start time.Time, interval time.Duration) *sampler {
+	s := &sampler{stopc: make(chan struct{}), done: make(chan struct{})}
+	statusPath := fmt.Sprintf("/proc/%d/status", pid)
+	ioPath := fmt.Sprintf("/proc/%d/io", pid)
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stopc:
+				return
+			case now := <-ticker.C:
+				p := Point{At: now.Sub(start)}
+				rss, ok := procField(statusPath, "VmRSS:")
+				if !ok {
+					continue // Exited (zombies have no VmRSS)
+				}
+				p.RSS = rss * 1024 // kB
+				p.ReadBytes, _ = procField(ioPath, "read_bytes:")
+				p.ReadCalls, _ = procField(ioPath, "syscr:")
+				p.ReadChars, _ = procField(ioPath, "rchar:")
+				s.series = append(s.series, p)
+			}
+		}
+	}()
+	return s
+}
+
+// stop stops polling and returns the series. It is safe on a nil sampler.
+func (s *sampler) stop() []Point {
+	if s == nil {
+		return nil
+	}
+	close(s.stopc)
+	<-s.done
+	return s.series
+}
+
+// procField returns the first number after key in a /proc file of
+// "key value" lines.
+func procField(path, key string) (int64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line, ok := bytes.CutPrefix(sc.Bytes(), []byte(key))
+		if !ok {
+			continue
+		}
+		fields := bytes.Fields(line)
+		if len(fields) == 0 {
+			return 0, false
+		}
+		n, err := strconv.ParseInt(string(fields[0]), 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package runner
+
+import "time"
+
+// sampler is empty: sampling reads /proc, which needs Linux.
+type sampler struct{}
+
+// startSampler returns nil: sampling reads /proc, which needs Linux.
+func startSampler(pid int, start time.Time, interval time.Duration) *sampler {
+	return nil
+}
+
+func (s *sampler) stop() []Point { return nil }
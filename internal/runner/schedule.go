@@ -0,0 +1,285 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/kolkov/uawk-bench/internal/stats"
+)
+
+// Schedule controls the order in which runs of different cells execute.
+type Schedule string
+
+const (
+	// Sequential runs all warmups and measured runs of one cell before
+	// moving to the next (the classic order).
+	Sequential Schedule = "sequential"
+	// RoundRobin runs one iteration of every cell per round, so drift
+	// (thermal throttling, background load) is spread across cells.
+	RoundRobin Schedule = "roundrobin"
+	// Shuffle is like RoundRobin but randomizes the order within each
+	// round using Runner.Seed.
+	Shuffle Schedule = "shuffle"
+)
+
+// ParseSchedule converts a schedule name to a Schedule.
+func ParseSchedule(s string) (Schedule, error) {
+	switch Schedule(s) {
+	case Sequential, RoundRobin, Shuffle:
+		return Schedule(s), nil
+	case "":
+		return Sequential, nil
+	default:
+		return "", fmt.Errorf("unknown schedule %q (use sequential, roundrobin, shuffle)", s)
+	}
+}
+
+// Cell is one benchmarked combination of AWK, program and input.
+type Cell struct {
+	AWK       AWK
+	Program   string
+	Inputs    []string  // Input files, passed in order
+	InputSize int64     // Total size of the inputs
+	InputMode InputMode // Input delivery (empty = Runner.InputMode)
+	Layout    string    // Label of the input layout, copied to the result
+	Locale    string    // LC_ALL for the AWK (empty = Runner.Locale)
+}
+
+// cellState collects measurements of one cell while it is scheduled.
+type cellState struct {
+	samples  []Sample
+	warmups  []Sample
+	failures []Sample      // Failed runs, including retried ones
+	lost     int           // Measured runs given up on with FailContinue
+	elapsed  time.Duration // Total measured time (for Runner.Budget)
+	err      error
+}
+
+// BenchmarkCells benchmarks all cells in the order given by r.Schedule.
+// It returns one result and one error per cell. Failed runs are handled
+// according to r.OnFailure; a cell that is aborted stops being scheduled
+// and gets a Failed result with its failed runs, the others continue.
+// Cells whose arguments exceed ARG_MAX (see CheckArgs) are never run.
+func (r *Runner) BenchmarkCells(ctx context.Context, cells []Cell) ([]*BenchmarkResult, []error) {
+	states := make([]cellState, len(cells))
+	for i, c := range cells {
+		states[i].err = r.CheckArgs(c)
+	}
+
+	switch r.Schedule {
+	case RoundRobin, Shuffle:
+		rng := rand.New(rand.NewSource(r.Seed))
+		for round := 0; round < r.Warmup; round++ {
+			for _, i := range r.order(len(cells), rng) {
+				r.runCell(ctx, cells[i], &states[i], true)
+			}
+		}
+		for r.anyNeedsMore(states) {
+			for _, i := range r.order(len(cells), rng) {
+				if r.needsMore(&states[i]) {
+					r.runCell(ctx, cells[i], &states[i], false)
+				}
+			}
+		}
+	default:
+		for i := range cells {
+			for w := 0; w < r.Warmup; w++ {
+				r.runCell(ctx, cells[i], &states[i], true)
+			}
+			for r.needsMore(&states[i]) {
+				r.runCell(ctx, cells[i], &states[i], false)
+			}
+		}
+	}
+
+	results := make([]*BenchmarkResult, len(cells))
+	errs := make([]error, len(cells))
+	for i, st := range states {
+		c := cells[i]
+		if st.err == nil && len(st.samples) == 0 && len(st.failures) > 0 {
+			st.err = errors.New(st.failures[len(st.failures)-1].Error) // Every run failed
+		}
+		if st.err != nil {
+			errs[i] = st.err
+			results[i] = r.failedResult(c, st)
+			continue
+		}
+		durations := sampleDurations(st.samples)
+		outliers := stats.Outliers(Seconds(durations), r.OutlierMethod)
+		// Resource summaries cover the same runs as the timing statistics
+		used := st.samples
+		if r.ExcludeOutliers {
+			used = withoutIndices(st.samples, outliers)
+		}
+		kept := sampleDurations(used)
+
+		res := calculateStats(c.AWK.Name, c.Program, kept, c.InputSize)
+		if res != nil {
+			res.Usage = summarizeUsage(sampleUsages(used))
+			res.Counters = summarizeCounters(used, c.InputSize)
+			res.Memory = summarizeSeries(used, r.SampleInterval)
+			res.ReadRate = summarizeTimeline(used, r.SlowdownThreshold)
+			res.GC = summarizeGC(used)
+			res.CPUs = r.cpuSet(c.AWK)
+			res.Nice = r.Nice
+			res.Schedule = string(r.schedule())
+			res.InputMode = string(r.inputMode(c))
+			res.Layout = c.Layout
+			res.Locale = r.locale(c)
+			res.Params = c.AWK.Params
+			res.CacheMode = string(r.cacheMode())
+			res.Residency = meanResidency(used)
+			res.Seed = r.Seed
+			res.Precision, _ = relativePrecision(kept)
+			res.Samples = st.samples
+			res.Warmups = st.warmups
+			res.Outliers = outliers
+			res.Robust = r.ExcludeOutliers
+			res.Failures = st.failures
+		}
+		results[i] = res
+	}
+	return results, errs
+}
+
+// failedResult describes an aborted cell by its last failed run.
+func (r *Runner) failedResult(c Cell, st cellState) *BenchmarkResult {
+	res := &BenchmarkResult{
+		AWK:       c.AWK.Name,
+		Program:   c.Program,
+		Runs:      len(st.samples),
+		Schedule:  string(r.schedule()),
+		InputMode: string(r.inputMode(c)),
+		Layout:    c.Layout,
+		Locale:    r.locale(c),
+		Params:    c.AWK.Params,
+		CacheMode: string(r.cacheMode()),
+		Residency: -1,
+		Seed:      r.Seed,
+		Samples:   st.samples,
+		Warmups:   st.warmups,
+		Failures:  st.failures,
+		Failed:    true,
+	}
+	if n := len(st.failures); n > 0 {
+		last := st.failures[n-1]
+		res.FailReason = last.Status.String()
+		if last.Stderr != "" {
+			res.FailReason += ": " + last.Stderr
+		}
+	} else {
+		res.FailReason = st.err.Error()
+	}
+	return res
+}
+
+// withoutIndices returns samples with the given indices removed.
+func withoutIndices(samples []Sample, idx []int) []Sample {
+	drop := make(map[int]bool, len(idx))
+	for _, i := range idx {
+		drop[i] = true
+	}
+	kept := make([]Sample, 0, len(samples)-len(idx))
+	for i, s := range samples {
+		if !drop[i] {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// runCell executes one warmup or measured run of a cell, repeating it
+// after a failure if r.OnFailure allows.
+func (r *Runner) runCell(ctx context.Context, c Cell, st *cellState, warmup bool) {
+	if st.err != nil {
+		return
+	}
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			st.err = err // Interrupted: stop scheduling the cell
+			return
+		}
+
+		resident, err := r.prepareCache(c.Inputs)
+		if err != nil {
+			st.err = err
+			return
+		}
+
+		result := r.RunMode(ctx, withLocale(c.AWK, c.Locale), c.Program, c.Inputs, r.inputMode(c))
+		sample := newSample(result, warmup)
+		sample.Residency = resident
+		if !warmup {
+			st.elapsed += result.Duration
+		}
+
+		if result.Error == nil {
+			if warmup {
+				st.warmups = append(st.warmups, sample)
+			} else {
+				st.samples = append(st.samples, sample)
+			}
+			return
+		}
+
+		st.failures = append(st.failures, sample)
+		switch {
+		case ctx.Err() != nil:
+			st.err = result.Error
+		case r.OnFailure == FailRetry && attempt < r.Retries:
+			continue
+		case r.OnFailure == FailContinue:
+			if !warmup {
+				st.lost++
+			}
+		default:
+			st.err = result.Error
+		}
+		return
+	}
+}
+
+// anyNeedsMore reports whether any cell needs another measured run.
+func (r *Runner) anyNeedsMore(states []cellState) bool {
+	for i := range states {
+		if r.needsMore(&states[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// inputMode returns the effective input mode of a cell.
+func (r *Runner) inputMode(c Cell) InputMode {
+	switch {
+	case c.InputMode != "":
+		return c.InputMode
+	case r.InputMode != "":
+		return r.InputMode
+	default:
+		return InputFile
+	}
+}
+
+// order returns the cell order for one round.
+func (r *Runner) order(n int, rng *rand.Rand) []int {
+	if r.Schedule == Shuffle {
+		return rng.Perm(n)
+	}
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	return idx
+}
+
+// schedule returns the effective schedule.
+func (r *Runner) schedule() Schedule {
+	if r.Schedule == "" {
+		return Sequential
+	}
+	return r.Schedule
+}
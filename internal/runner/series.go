@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"sort"
+	"time"
+)
+
+// Point is one reading of a running AWK process (from /proc).
+type Point struct {
+	At        time.Duration // Since the AWK was started
+	RSS       int64         // Resident set size in bytes (VmRSS)
+	ReadBytes int64         // Bytes fetched from storage so far (read_bytes)
+	ReadCalls int64         // Read system calls so far (syscr)
+	ReadChars int64         // Bytes returned by read system calls so far (rchar)
+}
+
+// MemoryStats summarizes the sampled series of a cell's runs.
+type MemoryStats struct {
+	Interval time.Duration // Sampling interval
+	PeakRSS  int64         // Median over runs of the highest sampled RSS
+	AvgRSS   int64         // Median over runs of the mean sampled RSS
+	Series   []Point       // Series of the run with the median duration
+}
+
+// summarizeSeries aggregates the sampled series of samples, or returns
+// nil if none were collected.
+func summarizeSeries(samples []Sample, interval time.Duration) *MemoryStats {
+	var peaks, avgs []int64
+	var sampled []Sample
+	for _, s := range samples {
+		if len(s.Series) == 0 {
+			continue
+		}
+		var peak, sum int64
+		for _, p := range s.Series {
+			peak = max(peak, p.RSS)
+			sum += p.RSS
+		}
+		peaks = append(peaks, peak)
+		avgs = append(avgs, sum/int64(len(s.Series)))
+		sampled = append(sampled, s)
+	}
+	if len(sampled) == 0 {
+		return nil
+	}
+
+	sort.Slice(sampled, func(i, j int) bool { return sampled[i].Duration < sampled[j].Duration })
+	return &MemoryStats{
+		Interval: interval,
+		PeakRSS:  summarize(peaks).Median,
+		AvgRSS:   summarize(avgs).Median,
+		Series:   sampled[len(sampled)/2].Series,
+	}
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+)
+
+// Digest identifies program output without holding it in memory.
+type Digest struct {
+	Bytes  int64  // Output size
+	Lines  int64  // Number of lines (a trailing partial line counts)
+	SHA256 string // Hex-encoded SHA-256 of the output
+}
+
+// Sink consumes program output as it is produced. It counts bytes and
+// lines, hashes the stream and keeps at most Limit bytes of it for
+// diagnostics, so large outputs are never buffered in full.
+type Sink struct {
+	Limit int // Bytes of output to keep (negative = keep everything)
+
+	bytes  int64
+	lines  int64
+	last   byte
+	hash   hash.Hash
+	prefix bytes.Buffer
+}
+
+// NewSink creates a sink that keeps up to limit bytes of output.
+// A negative limit keeps the whole output.
+func NewSink(limit int) *Sink {
+	return &Sink{
+		Limit: limit,
+		hash:  sha256.New(),
+	}
+}
+
+// Write implements io.Writer.
+func (s *Sink) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	s.hash.Write(p)
+	s.bytes += int64(len(p))
+	s.lines += int64(bytes.Count(p, []byte{'\n'}))
+	s.last = p[len(p)-1]
+
+	if s.Limit < 0 {
+		s.prefix.Write(p)
+	} else if room := s.Limit - s.prefix.Len(); room > 0 {
+		s.prefix.Write(p[:min(room, len(p))])
+	}
+	return len(p), nil
+}
+
+// Digest returns the size, line count and hash of everything written.
+func (s *Sink) Digest() Digest {
+	lines := s.lines
+	if s.bytes > 0 && s.last != '\n' {
+		lines++
+	}
+	return Digest{
+		Bytes:  s.bytes,
+		Lines:  lines,
+		SHA256: hex.EncodeToString(s.hash.Sum(nil)),
+	}
+}
+
+// Output returns the kept output: a prefix of at most Limit bytes, or
+// everything if the limit is negative.
+func (s *Sink) Output() string {
+	return s.prefix.String()
+}
+
+// Truncated reports whether Output holds less than was written.
+func (s *Sink) Truncated() bool {
+	return int64(s.prefix.Len()) < s.bytes
+}
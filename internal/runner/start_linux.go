@@ -0,0 +1,99 @@
+//go:build linux
+
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// cpuMask is a sched_setaffinity CPU mask for up to 1024 CPUs.
+type cpuMask [16]uint64
+
+// startProcess starts cmd with its CPU affinity restricted to cpus and
+// its nice value set to nice, and returns the time it was started. If
+// perf is not nil, its counters are opened for the child.
+//
+// Affinity, priority and performance counters are per-thread on Linux
+// and inherited across fork (and exec), so they are applied to a
+// dedicated OS thread that then starts the child. The goroutine exits
+// while still locked, which makes the runtime discard the thread
+// instead of reusing it.
+func startProcess(cmd *exec.Cmd, cpus []int, nice int, perf *counterSet) (time.Time, error) {
+	if len(cpus) == 0 && nice == 0 && perf == nil {
+		start := time.Now()
+		return start, cmd.Start()
+	}
+
+	var mask cpuMask
+	for _, cpu := range cpus {
+		if cpu < 0 || cpu >= len(mask)*64 {
+			return time.Time{}, fmt.Errorf("CPU %d out of range", cpu)
+		}
+		mask[cpu/64] |= 1 << (cpu % 64)
+	}
+
+	type started struct {
+		at  time.Time
+		err error
+	}
+	done := make(chan started, 1)
+	go func() {
+		runtime.LockOSThread() // never unlocked: the thread dies with the goroutine
+
+		if len(cpus) > 0 {
+			if err := setAffinity(&mask); err != nil {
+				done <- started{err: fmt.Errorf("sched_setaffinity %s: %w", FormatCPUList(cpus), err)}
+				return
+			}
+		}
+		if nice != 0 {
+			if err := syscall.Setpriority(syscall.PRIO_PROCESS, syscall.Gettid(), nice); err != nil {
+				done <- started{err: fmt.Errorf("setpriority %d: %w", nice, err)}
+				return
+			}
+		}
+
+		if perf != nil {
+			perf.open()
+		}
+
+		at := time.Now()
+		done <- started{at: at, err: cmd.Start()}
+	}()
+
+	s := <-done
+	return s.at, s.err
+}
+
+// setAffinity sets the calling thread's CPU mask.
+func setAffinity(mask *cpuMask) error {
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, 0, unsafe.Sizeof(*mask), uintptr(unsafe.Pointer(mask)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// AllowedCPUs returns the CPUs the harness may run on, from its own
+// affinity mask.
+func AllowedCPUs() ([]int, error) {
+	var mask cpuMask
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_GETAFFINITY, 0, unsafe.Sizeof(mask), uintptr(unsafe.Pointer(&mask)))
+	if errno != 0 {
+		return nil, errno
+	}
+	var cpus []int
+	for i, word := range mask {
+		for bit := 0; bit < 64; bit++ {
+			if word&(1<<bit) != 0 {
+				cpus = append(cpus, i*64+bit)
+			}
+		}
+	}
+	return cpus, nil
+}
@@ -0,0 +1,24 @@
+//go:build !unix
+
+package runner
+
+import (
+	"errors"
+	"os/exec"
+	"time"
+)
+
+// startProcess starts cmd. CPU pinning, nice and performance counters
+// (perf is always nil) are not supported.
+func startProcess(cmd *exec.Cmd, cpus []int, nice int, perf *counterSet) (time.Time, error) {
+	if len(cpus) > 0 || nice != 0 {
+		return time.Time{}, errors.New("CPU pinning and nice are not supported on this platform")
+	}
+	start := time.Now()
+	return start, cmd.Start()
+}
+
+// AllowedCPUs returns nil: CPU pinning is not supported on this platform.
+func AllowedCPUs() ([]int, error) {
+	return nil, nil
+}
@@ -0,0 +1,37 @@
+//go:build unix && !linux
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// startProcess starts cmd and sets its nice value. CPU pinning and
+// performance counters (perf is always nil) are only supported on Linux.
+func startProcess(cmd *exec.Cmd, cpus []int, nice int, perf *counterSet) (time.Time, error) {
+	if len(cpus) > 0 {
+		return time.Time{}, errors.New("CPU pinning is only supported on Linux")
+	}
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return start, err
+	}
+	if nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, cmd.Process.Pid, nice); err != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+			return start, fmt.Errorf("setpriority %d: %w", nice, err)
+		}
+	}
+	return start, nil
+}
+
+// AllowedCPUs returns nil: CPU pinning is only supported on Linux.
+func AllowedCPUs() ([]int, error) {
+	return nil, nil
+}
@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// StartupProgram labels the results of the startup probe.
+const StartupProgram = "BEGIN{}"
+
+// HarnessName names the startup baseline: /bin/true exits immediately,
+// so its time is the harness's own fork, exec and wait cost.
+const HarnessName = "harness"
+
+// ProbeStartup measures the startup cost of each AWK under each locale
+// (none = Runner.Locale) by running BEGIN{} without input, with the same
+// warmups, run count and schedule as the benchmarks. A /bin/true
+// baseline is probed first if available. Results are labeled
+// StartupProgram; errors name the failing AWK.
+func (r *Runner) ProbeStartup(ctx context.Context, awks []AWK, locales []string) ([]BenchmarkResult, []error) {
+	f, err := os.CreateTemp("", "awkbench-startup-*.awk")
+	if err != nil {
+		return nil, []error{err}
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("BEGIN {}\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	var cells []Cell
+	if path, err := exec.LookPath("true"); err == nil {
+		// true ignores the -f argument
+		cells = append(cells, Cell{AWK: AWK{Name: HarnessName, Command: path}, Program: f.Name()})
+	}
+	if len(locales) == 0 {
+		locales = []string{""}
+	}
+	for _, locale := range locales {
+		for _, awk := range awks {
+			cells = append(cells, Cell{AWK: awk, Program: f.Name(), Locale: locale})
+		}
+	}
+
+	results, errs := r.BenchmarkCells(ctx, cells)
+	var probes []BenchmarkResult
+	var failures []error
+	for i, res := range results {
+		if errs[i] != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", cells[i].AWK.Name, errs[i]))
+			continue
+		}
+		res.Program = StartupProgram
+		probes = append(probes, *res)
+	}
+	return probes, failures
+}
+
+// SubtractStartup sets Startup, Net and NetError of every result from
+// the startup probe of its AWK under the same locale. Failed results
+// are left alone. The uncertainty of Net combines the 95% CI
+// half-widths of both medians in quadrature.
+func SubtractStartup(results, startup []BenchmarkResult) {
+	type key struct{ awk, locale string }
+	probes := make(map[key]BenchmarkResult, len(startup))
+	for _, s := range startup {
+		if !s.Failed {
+			probes[key{s.AWK, s.Locale}] = s
+		}
+	}
+	for i := range results {
+		r := &results[i]
+		s, ok := probes[key{r.AWK, r.Locale}]
+		if !ok || r.Failed || r.Program == StartupProgram {
+			continue
+		}
+		r.Startup = s.Median
+		r.Net = r.Median - s.Median
+		r.NetError = time.Duration(math.Hypot(r.HalfWidth(), s.HalfWidth()))
+	}
+}
+
+// HalfWidth returns half the width of the 95% CI of the median.
+func (r BenchmarkResult) HalfWidth() float64 {
+	return float64(r.MedianHigh-r.MedianLow) / 2
+}
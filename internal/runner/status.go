@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StatusKind classifies how a run ended.
+type StatusKind string
+
+const (
+	StatusOK       StatusKind = "ok"
+	StatusTimeout  StatusKind = "timeout"  // Killed after Runner.Timeout
+	StatusExit     StatusKind = "exit"     // Non-zero exit code
+	StatusSignal   StatusKind = "signal"   // Killed by a signal
+	StatusOOM      StatusKind = "oom"      // SIGKILL while the OOM kill count went up
+	StatusKilled   StatusKind = "killed"   // Other SIGKILL not sent by the harness
+	StatusError    StatusKind = "error"    // Could not be started
+	StatusCanceled StatusKind = "canceled" // Stopped by an interrupt
+)
+
+// Status describes how a run ended.
+type Status struct {
+	Kind StatusKind
+	Code int // Exit code (exit) or signal number (signal, oom, killed)
+}
+
+// OK reports whether the run succeeded.
+func (s Status) OK() bool {
+	return s.Kind == StatusOK || s.Kind == ""
+}
+
+// String returns e.g. "ok", "exit 2", "signal 11" or "timeout".
+func (s Status) String() string {
+	switch s.Kind {
+	case "":
+		return string(StatusOK)
+	case StatusExit, StatusSignal:
+		return fmt.Sprintf("%s %d", s.Kind, s.Code)
+	default:
+		return string(s.Kind)
+	}
+}
+
+// FailurePolicy controls what happens when a run fails.
+type FailurePolicy string
+
+const (
+	// FailAbort stops scheduling the cell at its first failed run.
+	FailAbort FailurePolicy = "abort"
+	// FailRetry repeats a failed run up to Runner.Retries times before
+	// aborting the cell.
+	FailRetry FailurePolicy = "retry"
+	// FailContinue records the failed run and moves on; statistics use
+	// the successful runs.
+	FailContinue FailurePolicy = "continue"
+)
+
+// ParseFailurePolicy converts a policy name to a FailurePolicy.
+func ParseFailurePolicy(s string) (FailurePolicy, error) {
+	switch FailurePolicy(s) {
+	case FailAbort, FailRetry, FailContinue:
+		return FailurePolicy(s), nil
+	case "":
+		return FailAbort, nil
+	default:
+		return "", fmt.Errorf("unknown failure policy %q (use abort, retry, continue)", s)
+	}
+}
+
+// excerpt trims stderr to its first few lines for reports.
+func excerpt(stderr string) string {
+	const maxLines, maxBytes = 3, 240
+
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	if len(lines) > maxLines {
+		lines = append(lines[:maxLines], "...")
+	}
+	s := strings.Join(lines, "\n")
+	if len(s) > maxBytes {
+		s = s[:maxBytes] + "..."
+	}
+	return s
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package runner
+
+import "os"
+
+// exitSignal reports no signal: processes are not killed by signals.
+func exitSignal(ps *os.ProcessState) (signal, bool) {
+	return 0, false
+}
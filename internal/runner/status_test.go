@@ -0,0 +1,52 @@
+package runner
+
+import "testing"
+
+func TestParseFailurePolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    FailurePolicy
+		wantErr bool
+	}{
+		{"", FailAbort, false},
+		{"abort", FailAbort, false},
+		{"retry", FailRetry, false},
+		{"continue", FailContinue, false},
+		{"Abort", "", true},
+		{"ignore", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFailurePolicy(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFailurePolicy(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseFailurePolicy(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStatusString(t *testing.T) {
+	tests := []struct {
+		status Status
+		want   string
+		ok     bool
+	}{
+		{Status{}, "ok", true},
+		{Status{Kind: StatusOK}, "ok", true},
+		{Status{Kind: StatusExit, Code: 2}, "exit 2", false},
+		{Status{Kind: StatusSignal, Code: 11}, "signal 11", false},
+		{Status{Kind: StatusOOM, Code: 9}, "oom", false},
+		{Status{Kind: StatusKilled, Code: 9}, "killed", false},
+		{Status{Kind: StatusTimeout}, "timeout", false},
+	}
+	for _, tt := range tests {
+		if got := tt.status.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.status, got, tt.want)
+		}
+		if got := tt.status.OK(); got != tt.ok {
+			t.Errorf("%+v.OK() = %v, want %v", tt.status, got, tt.ok)
+		}
+	}
+}
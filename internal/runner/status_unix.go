@@ -0,0 +1,17 @@
+//go:build unix
+
+package runner
+
+import (
+	"os"
+	"syscall"
+)
+
+// exitSignal returns the signal that killed the process, if any.
+func exitSignal(ps *os.ProcessState) (signal, bool) {
+	ws, ok := ps.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return 0, false
+	}
+	return ws.Signal(), true
+}
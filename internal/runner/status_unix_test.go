@@ -0,0 +1,60 @@
+//go:build unix
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// exitState runs a shell script and returns how it ended.
+func exitState(t *testing.T, script string) *os.ProcessState {
+	t.Helper()
+	cmd := exec.Command("sh", "-c", script)
+	cmd.Run()
+	if cmd.ProcessState == nil {
+		t.Fatalf("sh -c %q did not run", script)
+	}
+	return cmd.ProcessState
+}
+
+func TestClassify(t *testing.T) {
+	exit3 := exitState(t, "exit 3")
+	segv := exitState(t, "kill -SEGV $$")
+	kill := exitState(t, "kill -KILL $$")
+	term := exitState(t, "kill -TERM $$")
+	deadline := fmt.Errorf("wrapped: %w", context.DeadlineExceeded)
+
+	tests := []struct {
+		name    string
+		ps      *os.ProcessState
+		stopped bool
+		cause   error
+		oom     bool
+		want    Status
+	}{
+		{"exit code", exit3, false, nil, false, Status{Kind: StatusExit, Code: 3}},
+		{"signal", segv, false, nil, false, Status{Kind: StatusSignal, Code: 11}},
+		{"sigterm", term, false, nil, false, Status{Kind: StatusSignal, Code: 15}},
+		{"stray sigkill", kill, false, nil, false, Status{Kind: StatusKilled, Code: 9}},
+		{"oom kill", kill, false, nil, true, Status{Kind: StatusOOM, Code: 9}},
+		{"oom count, other signal", segv, false, nil, true, Status{Kind: StatusSignal, Code: 11}},
+		{"oom count, exit code", exit3, false, nil, true, Status{Kind: StatusExit, Code: 3}},
+		{"timeout sigterm", term, true, deadline, false, Status{Kind: StatusTimeout}},
+		{"timeout sigkill", kill, true, deadline, true, Status{Kind: StatusTimeout}},
+		{"timeout exit", exit3, true, deadline, false, Status{Kind: StatusTimeout}},
+		{"interrupt", term, true, context.Canceled, false, Status{Kind: StatusCanceled}},
+		{"interrupt sigkill", kill, true, context.Canceled, true, Status{Kind: StatusCanceled}},
+		{"canceled before start", nil, false, context.Canceled, false, Status{Kind: StatusCanceled}},
+		{"not started", nil, false, nil, false, Status{Kind: StatusError}},
+		{"not started, deadline", nil, false, deadline, false, Status{Kind: StatusError}},
+	}
+	for _, tt := range tests {
+		if got := classify(tt.ps, tt.stopped, tt.cause, tt.oom); got != tt.want {
+			t.Errorf("%s: classify = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package runner
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kolkov/uawk-bench/internal/stats"
+)
+
+// Rate is the read throughput of a run between two samples.
+type Rate struct {
+	At   time.Duration // End of the interval, since the AWK was started
+	MBps float64       // Bytes read per second in the interval / 2^20
+}
+
+// minTimelinePoints is the number of samples in the reading phase of a
+// run below which its quarters are not compared.
+const minTimelinePoints = 8
+
+// readingPhase returns the samples up to the one where reading ended,
+// i.e. where rchar reached its final value; the END block and exit are
+// excluded, so they do not count as a slowdown.
+func readingPhase(series []Point) []Point {
+	if len(series) == 0 {
+		return nil
+	}
+	final := series[len(series)-1].ReadChars
+	for i, p := range series {
+		if p.ReadChars == final {
+			return series[:i+1]
+		}
+	}
+	return series
+}
+
+// readTimeline returns the read throughput between consecutive samples
+// of the reading phase of a run.
+func readTimeline(series []Point) []Rate {
+	phase := readingPhase(series)
+	var rates []Rate
+	prev := Point{} // Nothing read at start
+	for _, p := range phase {
+		if dt := p.At - prev.At; dt > 0 {
+			rates = append(rates, Rate{At: p.At, MBps: mbps(p.ReadChars-prev.ReadChars, dt)})
+		}
+		prev = p
+	}
+	return rates
+}
+
+// quarterRates returns the read throughput of a run over the first and
+// the last quarter of its reading phase, interpolating rchar between
+// samples. ok is false if the phase has too few samples.
+func quarterRates(series []Point) (first, last float64, ok bool) {
+	phase := readingPhase(series)
+	if len(phase) < minTimelinePoints {
+		return 0, 0, false
+	}
+	end := phase[len(phase)-1].At
+	q := end / 4
+	first = mbps(readAt(phase, q), q)
+	last = mbps(readAt(phase, end)-readAt(phase, end-q), q)
+	return first, last, true
+}
+
+// readAt interpolates rchar at time t, from 0 at start.
+func readAt(series []Point, t time.Duration) int64 {
+	prev := Point{}
+	for _, p := range series {
+		if p.At >= t {
+			if p.At == prev.At {
+				return p.ReadChars
+			}
+			frac := float64(t-prev.At) / float64(p.At-prev.At)
+			return prev.ReadChars + int64(frac*float64(p.ReadChars-prev.ReadChars))
+		}
+		prev = p
+	}
+	return prev.ReadChars
+}
+
+// mbps converts bytes over d to MB/s.
+func mbps(bytes int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(bytes) / (1 << 20) / d.Seconds()
+}
+
+// ReadStats compares the read throughput of a cell's runs early and
+// late in their reading phase.
+type ReadStats struct {
+	FirstQuarter float64 // Median MB/s over the first quarter
+	LastQuarter  float64 // Median MB/s over the last quarter
+	Drop         float64 // 1 - LastQuarter/FirstQuarter (negative = sped up)
+	Threshold    float64 // Drop above which the cell is flagged
+	Flagged      bool    // Drop exceeds Threshold
+	Timeline     []Rate  // Timeline of the run with the median time
+}
+
+// summarizeTimeline compares quarters across the sampled runs of a cell,
+// or returns nil if no run has enough samples.
+func summarizeTimeline(samples []Sample, threshold float64) *ReadStats {
+	var firsts, lasts []float64
+	var timed []Sample
+	for _, s := range samples {
+		first, last, ok := quarterRates(s.Series)
+		if !ok {
+			continue
+		}
+		firsts = append(firsts, first)
+		lasts = append(lasts, last)
+		timed = append(timed, s)
+	}
+	if len(timed) == 0 {
+		return nil
+	}
+
+	st := &ReadStats{
+		FirstQuarter: stats.Median(firsts),
+		LastQuarter:  stats.Median(lasts),
+		Threshold:    threshold,
+	}
+	if st.FirstQuarter > 0 {
+		st.Drop = 1 - st.LastQuarter/st.FirstQuarter
+	}
+	st.Flagged = threshold > 0 && st.Drop > threshold
+
+	sort.Slice(timed, func(i, j int) bool { return timed[i].Duration < timed[j].Duration })
+	st.Timeline = timed[len(timed)/2].Timeline
+	return st
+}
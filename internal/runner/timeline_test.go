@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// readSeries returns a series sampled every 10ms that reads the given
+// KiB in each interval; 1024 KiB per interval is 100 MB/s.
+func readSeries(kib ...int64) []Point {
+	var series []Point
+	var read int64
+	for i, k := range kib {
+		read += k << 10
+		series = append(series, Point{At: time.Duration(i+1) * 10 * time.Millisecond, ReadChars: read})
+	}
+	return series
+}
+
+// repeat returns n copies of kib.
+func repeat(kib int64, n int) []int64 {
+	s := make([]int64, n)
+	for i := range s {
+		s[i] = kib
+	}
+	return s
+}
+
+// concat joins the parts.
+func concat(parts ...[]int64) []int64 {
+	var s []int64
+	for _, p := range parts {
+		s = append(s, p...)
+	}
+	return s
+}
+
+func TestReadingPhase(t *testing.T) {
+	tests := []struct {
+		name   string
+		series []Point
+		want   int
+	}{
+		{"empty", nil, 0},
+		{"flat", readSeries(repeat(1024, 8)...), 8},
+		{"END leaves rchar flat", readSeries(concat(repeat(1024, 8), repeat(0, 5))...), 8},
+		{"nothing read", readSeries(0, 0, 0), 1},
+	}
+	for _, tt := range tests {
+		if got := readingPhase(tt.series); len(got) != tt.want {
+			t.Errorf("%s: readingPhase has %d points, want %d", tt.name, len(got), tt.want)
+		}
+	}
+}
+
+func TestReadAt(t *testing.T) {
+	series := readSeries(1024, 2048, 0, 1024) // 1, 3, 3, 4 MiB at 10..40ms
+	tests := []struct {
+		t    time.Duration
+		want int64
+	}{
+		{0, 0},
+		{5 * time.Millisecond, 512 << 10},
+		{10 * time.Millisecond, 1 << 20},
+		{15 * time.Millisecond, 2 << 20},
+		{25 * time.Millisecond, 3 << 20},
+		{40 * time.Millisecond, 4 << 20},
+		{time.Second, 4 << 20}, // Past the last sample
+	}
+	for _, tt := range tests {
+		if got := readAt(series, tt.t); got != tt.want {
+			t.Errorf("readAt(%v) = %d, want %d", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestQuarterRates(t *testing.T) {
+	tests := []struct {
+		name        string
+		kib         []int64
+		first, last float64
+		ok          bool
+	}{
+		{"flat", repeat(1024, 8), 100, 100, true},
+		{"drops late", concat(repeat(1024, 9), repeat(512, 3)), 100, 50, true},
+		{"END leaves rchar flat", concat(repeat(1024, 8), repeat(0, 8)), 100, 100, true},
+		{"too few points", repeat(1024, minTimelinePoints-1), 0, 0, false},
+		{"too few before END", concat(repeat(1024, minTimelinePoints-1), repeat(0, 10)), 0, 0, false},
+	}
+	for _, tt := range tests {
+		first, last, ok := quarterRates(readSeries(tt.kib...))
+		if ok != tt.ok || math.Abs(first-tt.first) > 1e-9 || math.Abs(last-tt.last) > 1e-9 {
+			t.Errorf("%s: quarterRates = %v, %v, %v; want %v, %v, %v", tt.name, first, last, ok, tt.first, tt.last, tt.ok)
+		}
+	}
+}
+
+func TestSummarizeTimeline(t *testing.T) {
+	run := func(d time.Duration, kib ...int64) Sample {
+		series := readSeries(kib...)
+		return Sample{Duration: d, Series: series, Timeline: readTimeline(series)}
+	}
+	flat := run(80*time.Millisecond, repeat(1024, 8)...)
+	late := run(120*time.Millisecond, concat(repeat(1024, 9), repeat(512, 3))...)
+	withEnd := run(160*time.Millisecond, concat(repeat(1024, 8), repeat(0, 8))...)
+	short := run(70*time.Millisecond, repeat(1024, minTimelinePoints-1)...)
+
+	tests := []struct {
+		name      string
+		samples   []Sample
+		threshold float64
+		want      *ReadStats
+	}{
+		{"flat", []Sample{flat, flat, flat}, 0.2, &ReadStats{
+			FirstQuarter: 100, LastQuarter: 100, Threshold: 0.2, Timeline: flat.Timeline,
+		}},
+		{"drops late", []Sample{late, late, late}, 0.2, &ReadStats{
+			FirstQuarter: 100, LastQuarter: 50, Drop: 0.5, Threshold: 0.2, Flagged: true, Timeline: late.Timeline,
+		}},
+		{"drop below threshold", []Sample{late}, 0.6, &ReadStats{
+			FirstQuarter: 100, LastQuarter: 50, Drop: 0.5, Threshold: 0.6, Timeline: late.Timeline,
+		}},
+		{"no threshold", []Sample{late}, 0, &ReadStats{
+			FirstQuarter: 100, LastQuarter: 50, Drop: 0.5, Timeline: late.Timeline,
+		}},
+		{"END leaves rchar flat", []Sample{withEnd, withEnd}, 0.2, &ReadStats{
+			FirstQuarter: 100, LastQuarter: 100, Threshold: 0.2, Timeline: withEnd.Timeline,
+		}},
+		{"median run", []Sample{late, flat, withEnd}, 0.2, &ReadStats{
+			FirstQuarter: 100, LastQuarter: 100, Threshold: 0.2, Timeline: late.Timeline,
+		}},
+		{"short runs skipped", []Sample{short, late}, 0.2, &ReadStats{
+			FirstQuarter: 100, LastQuarter: 50, Drop: 0.5, Threshold: 0.2, Flagged: true, Timeline: late.Timeline,
+		}},
+		{"too few points", []Sample{short, short}, 0.2, nil},
+		{"not sampled", []Sample{{Duration: time.Second}}, 0.2, nil},
+	}
+	for _, tt := range tests {
+		if got := summarizeTimeline(tt.samples, tt.threshold); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: summarizeTimeline = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"sort"
+	"time"
+)
+
+// Usage holds resource usage of a single AWK process (from getrusage).
+type Usage struct {
+	UserTime         time.Duration // CPU time spent in user mode
+	SysTime          time.Duration // CPU time spent in the kernel
+	MaxRSS           int64         // Peak resident set size in bytes
+	MinorFaults      int64         // Page faults served without I/O
+	MajorFaults      int64         // Page faults that required I/O
+	VolCtxSwitches   int64         // Voluntary context switches (blocking)
+	InvolCtxSwitches int64         // Involuntary context switches (preemption)
+}
+
+// Summary aggregates one metric across runs.
+// Units follow the metric: nanoseconds, bytes or counts.
+type Summary struct {
+	Mean   int64
+	Median int64
+	Max    int64
+}
+
+// UsageStats aggregates resource usage across runs.
+type UsageStats struct {
+	UserTime         Summary
+	SysTime          Summary
+	MaxRSS           Summary
+	MinorFaults      Summary
+	MajorFaults      Summary
+	VolCtxSwitches   Summary
+	InvolCtxSwitches Summary
+}
+
+// summarizeUsage aggregates per-run resource usage.
+func summarizeUsage(usages []Usage) UsageStats {
+	metric := func(get func(Usage) int64) Summary {
+		values := make([]int64, len(usages))
+		for i, u := range usages {
+			values[i] = get(u)
+		}
+		return summarize(values)
+	}
+
+	return UsageStats{
+		UserTime:         metric(func(u Usage) int64 { return int64(u.UserTime) }),
+		SysTime:          metric(func(u Usage) int64 { return int64(u.SysTime) }),
+		MaxRSS:           metric(func(u Usage) int64 { return u.MaxRSS }),
+		MinorFaults:      metric(func(u Usage) int64 { return u.MinorFaults }),
+		MajorFaults:      metric(func(u Usage) int64 { return u.MajorFaults }),
+		VolCtxSwitches:   metric(func(u Usage) int64 { return u.VolCtxSwitches }),
+		InvolCtxSwitches: metric(func(u Usage) int64 { return u.InvolCtxSwitches }),
+	}
+}
+
+// summarize computes mean, median and max of values.
+func summarize(values []int64) Summary {
+	n := len(values)
+	if n == 0 {
+		return Summary{}
+	}
+
+	sorted := make([]int64, n)
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum int64
+	for _, v := range values {
+		sum += v
+	}
+
+	median := sorted[n/2]
+	if n%2 == 0 {
+		median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+
+	return Summary{
+		Mean:   sum / int64(n),
+		Median: median,
+		Max:    sorted[n-1],
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package runner
+
+import "os"
+
+// processUsage returns empty usage: getrusage is not available.
+func processUsage(ps *os.ProcessState) Usage {
+	return Usage{}
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package runner
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// processUsage extracts resource usage from a finished process.
+func processUsage(ps *os.ProcessState) Usage {
+	if ps == nil {
+		return Usage{}
+	}
+	ru, ok := ps.SysUsage().(*syscall.Rusage)
+	if !ok || ru == nil {
+		return Usage{}
+	}
+
+	// ru_maxrss is in kilobytes everywhere except macOS
+	maxRSS := int64(ru.Maxrss)
+	if runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+		maxRSS *= 1024
+	}
+
+	return Usage{
+		UserTime:         time.Duration(ru.Utime.Nano()),
+		SysTime:          time.Duration(ru.Stime.Nano()),
+		MaxRSS:           maxRSS,
+		MinorFaults:      int64(ru.Minflt),
+		MajorFaults:      int64(ru.Majflt),
+		VolCtxSwitches:   int64(ru.Nvcsw),
+		InvolCtxSwitches: int64(ru.Nivcsw),
+	}
+}
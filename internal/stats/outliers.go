@@ -0,0 +1,96 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+)
+
+// OutlierMethod selects how outliers are classified.
+type OutlierMethod string
+
+const (
+	// Tukey flags values outside [Q1 - 1.5·IQR, Q3 + 1.5·IQR].
+	Tukey OutlierMethod = "tukey"
+	// MAD flags values whose modified z-score, 0.6745·|x - median| / MAD,
+	// exceeds 3.5 (Iglewicz and Hoaglin).
+	MAD OutlierMethod = "mad"
+	// NoOutliers disables classification.
+	NoOutliers OutlierMethod = "none"
+)
+
+// ParseOutlierMethod converts a method name to an OutlierMethod.
+func ParseOutlierMethod(s string) (OutlierMethod, error) {
+	switch OutlierMethod(s) {
+	case Tukey, MAD, NoOutliers:
+		return OutlierMethod(s), nil
+	case "":
+		return Tukey, nil
+	default:
+		return "", fmt.Errorf("unknown outlier method %q (use tukey, mad, none)", s)
+	}
+}
+
+// Outliers returns the indices of xs classified as outliers by method.
+// Fewer than four values are never classified.
+func Outliers(xs []float64, method OutlierMethod) []int {
+	if len(xs) < 4 {
+		return nil
+	}
+
+	var isOutlier func(x float64) bool
+	switch method {
+	case MAD:
+		median := Median(xs)
+		deviations := make([]float64, len(xs))
+		for i, x := range xs {
+			deviations[i] = math.Abs(x - median)
+		}
+		mad := Median(deviations)
+		if mad == 0 {
+			return nil
+		}
+		isOutlier = func(x float64) bool {
+			return 0.6745*math.Abs(x-median)/mad > 3.5
+		}
+	case NoOutliers:
+		return nil
+	default:
+		sorted := sortedCopy(xs)
+		q1, q3 := Quantile(sorted, 0.25), Quantile(sorted, 0.75)
+		fence := 1.5 * (q3 - q1)
+		isOutlier = func(x float64) bool {
+			return x < q1-fence || x > q3+fence
+		}
+	}
+
+	var idx []int
+	for i, x := range xs {
+		if isOutlier(x) {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// IQR returns the interquartile range of xs.
+func IQR(xs []float64) float64 {
+	sorted := sortedCopy(xs)
+	return Quantile(sorted, 0.75) - Quantile(sorted, 0.25)
+}
+
+// TrimmedMean returns the mean of xs after dropping the lowest and
+// highest trim fraction (e.g. 0.1 drops 10% from each end).
+func TrimmedMean(xs []float64, trim float64) float64 {
+	sorted := sortedCopy(xs)
+	k := int(float64(len(sorted)) * trim)
+	kept := sorted[k : len(sorted)-k]
+	if len(kept) == 0 {
+		return math.NaN()
+	}
+
+	var sum float64
+	for _, x := range kept {
+		sum += x
+	}
+	return sum / float64(len(kept))
+}
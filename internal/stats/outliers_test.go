@@ -0,0 +1,86 @@
+package stats
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestOutliers(t *testing.T) {
+	tests := []struct {
+		name   string
+		xs     []float64
+		method OutlierMethod
+		want   []int
+	}{
+		{"tukey high", []float64{10, 11, 10, 12, 11, 40}, Tukey, []int{5}},
+		{"tukey both", []float64{1, 10, 11, 10, 12, 11, 40}, Tukey, []int{0, 6}},
+		{"tukey none", []float64{10, 11, 12, 13, 14}, Tukey, nil},
+		{"tukey too few", []float64{1, 2, 100}, Tukey, nil},
+		{"mad high", []float64{10, 11, 10, 12, 11, 40}, MAD, []int{5}},
+		{"mad zero deviation", []float64{5, 5, 5, 5, 9}, MAD, nil},
+		{"mad within", []float64{10, 12, 14, 16, 18}, MAD, nil},
+		{"none", []float64{10, 11, 10, 12, 11, 40}, NoOutliers, nil},
+	}
+	for _, tt := range tests {
+		if got := Outliers(tt.xs, tt.method); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Outliers(%v, %s) = %v, want %v", tt.name, tt.xs, tt.method, got, tt.want)
+		}
+	}
+}
+
+func TestParseOutlierMethod(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    OutlierMethod
+		wantErr bool
+	}{
+		{"", Tukey, false},
+		{"tukey", Tukey, false},
+		{"mad", MAD, false},
+		{"none", NoOutliers, false},
+		{"grubbs", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseOutlierMethod(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseOutlierMethod(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestIQR(t *testing.T) {
+	tests := []struct {
+		xs   []float64
+		want float64
+	}{
+		{[]float64{1, 2, 3, 4, 5}, 2},
+		{[]float64{4, 3, 2, 1}, 1.5},
+		{[]float64{7, 7, 7}, 0},
+	}
+	for _, tt := range tests {
+		if got := IQR(tt.xs); got != tt.want {
+			t.Errorf("IQR(%v) = %v, want %v", tt.xs, got, tt.want)
+		}
+	}
+}
+
+func TestTrimmedMean(t *testing.T) {
+	tests := []struct {
+		xs   []float64
+		trim float64
+		want float64
+	}{
+		{[]float64{1, 2, 3, 4}, 0, 2.5},
+		{[]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 100}, 0.1, 5.5},
+		{[]float64{100, 5, 5, 5, 5, 5, 5, 5, 5, -100}, 0.1, 5},
+	}
+	for _, tt := range tests {
+		if got := TrimmedMean(tt.xs, tt.trim); got != tt.want {
+			t.Errorf("TrimmedMean(%v, %v) = %v, want %v", tt.xs, tt.trim, got, tt.want)
+		}
+	}
+	if got := TrimmedMean([]float64{1, 2}, 0.5); !math.IsNaN(got) {
+		t.Errorf("TrimmedMean(all trimmed) = %v, want NaN", got)
+	}
+}
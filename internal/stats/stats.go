@@ -0,0 +1,204 @@
+// Package stats provides the statistics used to compare benchmark samples:
+// bootstrap confidence intervals and the Mann-Whitney U test.
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// DefaultIterations is the number of bootstrap resamples.
+const DefaultIterations = 2000
+
+// Alpha is the significance level used to call a difference significant.
+const Alpha = 0.05
+
+// Median returns the median of xs.
+func Median(xs []float64) float64 {
+	sorted := sortedCopy(xs)
+	return Quantile(sorted, 0.5)
+}
+
+// Quantile returns the q-quantile of sorted data using linear
+// interpolation between closest ranks.
+func Quantile(sorted []float64, q float64) float64 {
+	n := len(sorted)
+	if n == 0 {
+		return math.NaN()
+	}
+	if n == 1 {
+		return sorted[0]
+	}
+	pos := q * float64(n-1)
+	i := int(math.Floor(pos))
+	if i >= n-1 {
+		return sorted[n-1]
+	}
+	frac := pos - float64(i)
+	return sorted[i] + frac*(sorted[i+1]-sorted[i])
+}
+
+// Bootstrap returns a percentile bootstrap confidence interval at the
+// given level (e.g. 0.95) for stat computed over xs.
+func Bootstrap(xs []float64, stat func([]float64) float64, iters int, level float64, rng *rand.Rand) (lo, hi float64) {
+	if len(xs) == 0 {
+		return math.NaN(), math.NaN()
+	}
+
+	estimates := make([]float64, iters)
+	sample := make([]float64, len(xs))
+	for i := range estimates {
+		resample(sample, xs, rng)
+		estimates[i] = stat(sample)
+	}
+	sort.Float64s(estimates)
+
+	tail := (1 - level) / 2
+	return Quantile(estimates, tail), Quantile(estimates, 1-tail)
+}
+
+// RatioCI returns the ratio of medians median(a)/median(b) with a
+// percentile bootstrap confidence interval, resampling a and b
+// independently.
+func RatioCI(a, b []float64, iters int, level float64, rng *rand.Rand) (ratio, lo, hi float64) {
+	if len(a) == 0 || len(b) == 0 {
+		return math.NaN(), math.NaN(), math.NaN()
+	}
+	ratio = Median(a) / Median(b)
+
+	estimates := make([]float64, iters)
+	sa := make([]float64, len(a))
+	sb := make([]float64, len(b))
+	for i := range estimates {
+		resample(sa, a, rng)
+		resample(sb, b, rng)
+		estimates[i] = Median(sa) / Median(sb)
+	}
+	sort.Float64s(estimates)
+
+	tail := (1 - level) / 2
+	return ratio, Quantile(estimates, tail), Quantile(estimates, 1-tail)
+}
+
+// MannWhitney performs a two-sided Mann-Whitney U test of whether a and b
+// come from the same distribution. It returns the U statistic for a and
+// the p-value. Small samples without ties use the exact distribution;
+// otherwise the normal approximation with tie and continuity correction.
+func MannWhitney(a, b []float64) (u, p float64) {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return math.NaN(), 1
+	}
+
+	// Rank the pooled samples, averaging ranks of ties
+	type obs struct {
+		v     float64
+		fromA bool
+	}
+	pooled := make([]obs, 0, n1+n2)
+	for _, v := range a {
+		pooled = append(pooled, obs{v, true})
+	}
+	for _, v := range b {
+		pooled = append(pooled, obs{v, false})
+	}
+	sort.Slice(pooled, func(i, j int) bool { return pooled[i].v < pooled[j].v })
+
+	var rankA, tieTerm float64
+	ties := false
+	for i := 0; i < len(pooled); {
+		j := i
+		for j < len(pooled) && pooled[j].v == pooled[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // average of ranks i+1..j
+		for k := i; k < j; k++ {
+			if pooled[k].fromA {
+				rankA += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieTerm += t*t*t - t
+		}
+		i = j
+	}
+
+	u = rankA - float64(n1*(n1+1))/2
+	if !ties && n1 <= 20 && n2 <= 20 {
+		return u, exactP(u, n1, n2)
+	}
+
+	nn := float64(n1 + n2)
+	mean := float64(n1*n2) / 2
+	variance := float64(n1*n2) / 12 * ((nn + 1) - tieTerm/(nn*(nn-1)))
+	if variance <= 0 {
+		return u, 1
+	}
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		z = 0
+	}
+	return u, math.Erfc(z / math.Sqrt2)
+}
+
+// exactP returns the exact two-sided p-value of U for sample sizes n1, n2.
+func exactP(u float64, n1, n2 int) float64 {
+	dist := uDistribution(n1, n2)
+	var total float64
+	for _, c := range dist {
+		total += c
+	}
+
+	// Two-sided: probability of a U at least as extreme as observed
+	mean := float64(n1*n2) / 2
+	dev := math.Abs(u - mean)
+	var extreme float64
+	for k, c := range dist {
+		if math.Abs(float64(k)-mean) >= dev-1e-9 {
+			extreme += c
+		}
+	}
+	return math.Min(1, extreme/total)
+}
+
+// uDistribution returns the number of arrangements giving each value of U
+// for sample sizes n1 and n2, using the recurrence
+// f(i, j, u) = f(i-1, j, u-j) + f(i, j-1, u).
+func uDistribution(n1, n2 int) []float64 {
+	prev := make([][]float64, n2+1) // row i-1
+	for j := range prev {
+		prev[j] = []float64{1} // f(0, j) = 1 at u = 0
+	}
+	for i := 1; i <= n1; i++ {
+		cur := make([][]float64, n2+1)
+		cur[0] = []float64{1} // f(i, 0) = 1 at u = 0
+		for j := 1; j <= n2; j++ {
+			dist := make([]float64, i*j+1)
+			for k, c := range prev[j] {
+				dist[k+j] += c
+			}
+			for k, c := range cur[j-1] {
+				dist[k] += c
+			}
+			cur[j] = dist
+		}
+		prev = cur
+	}
+	return prev[n2]
+}
+
+// resample fills dst with a bootstrap resample of src.
+func resample(dst, src []float64, rng *rand.Rand) {
+	for i := range dst {
+		dst[i] = src[rng.Intn(len(src))]
+	}
+}
+
+func sortedCopy(xs []float64) []float64 {
+	sorted := make([]float64, len(xs))
+	copy(sorted, xs)
+	sort.Float64s(sorted)
+	return sorted
+}
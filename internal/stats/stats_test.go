@@ -0,0 +1,160 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestQuantile(t *testing.T) {
+	tests := []struct {
+		sorted []float64
+		q      float64
+		want   float64
+	}{
+		{[]float64{7}, 0.5, 7},
+		{[]float64{1, 2, 3, 4}, 0, 1},
+		{[]float64{1, 2, 3, 4}, 0.25, 1.75},
+		{[]float64{1, 2, 3, 4}, 0.5, 2.5},
+		{[]float64{1, 2, 3, 4}, 1, 4},
+		{[]float64{1, 2, 3, 4, 5}, 0.5, 3},
+		{[]float64{10, 20}, 0.9, 19},
+	}
+	for _, tt := range tests {
+		if got := Quantile(tt.sorted, tt.q); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("Quantile(%v, %v) = %v, want %v", tt.sorted, tt.q, got, tt.want)
+		}
+	}
+	if got := Quantile(nil, 0.5); !math.IsNaN(got) {
+		t.Errorf("Quantile(nil) = %v, want NaN", got)
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		xs   []float64
+		want float64
+	}{
+		{[]float64{3, 1, 2}, 2},
+		{[]float64{4, 1, 3, 2}, 2.5},
+		{[]float64{5, 5, 5, 100}, 5},
+	}
+	for _, tt := range tests {
+		if got := Median(tt.xs); got != tt.want {
+			t.Errorf("Median(%v) = %v, want %v", tt.xs, got, tt.want)
+		}
+	}
+}
+
+func TestMannWhitney(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  []float64
+		wantU float64
+		wantP float64
+	}{
+		// Exact distribution: the two most extreme of C(10,5) = 252
+		// arrangements
+		{"exact 5v5 separated", []float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10}, 0, 2.0 / 252},
+		{"exact 5v5 reversed", []float64{6, 7, 8, 9, 10}, []float64{1, 2, 3, 4, 5}, 25, 2.0 / 252},
+		{"exact 3v3 separated", []float64{1, 2, 3}, []float64{4, 5, 6}, 0, 0.1},
+		{"exact interleaved", []float64{1, 4, 5, 8}, []float64{2, 3, 6, 7}, 8, 1},
+		// Normal approximation with tie and continuity correction
+		{"normal ties", []float64{1, 1, 2, 2, 3}, []float64{4, 4, 5, 5, 6}, 0, 0.011159425282914772},
+		{"normal overlap", []float64{1, 2, 3, 4, 5, 6}, []float64{4, 5, 6, 7, 8, 9}, 4.5, 0.03637858037213119},
+		{"normal identical", []float64{1, 2, 2, 3}, []float64{1, 2, 2, 3}, 8, 1},
+		{"normal large", seq(1, 25), seq(26, 50), 0, 1.4156562248495634e-09},
+	}
+	for _, tt := range tests {
+		u, p := MannWhitney(tt.a, tt.b)
+		if u != tt.wantU {
+			t.Errorf("%s: U = %v, want %v", tt.name, u, tt.wantU)
+		}
+		if math.Abs(p-tt.wantP) > 1e-6*math.Max(tt.wantP, 1e-3) {
+			t.Errorf("%s: p = %v, want %v", tt.name, p, tt.wantP)
+		}
+	}
+
+	if u, p := MannWhitney(nil, []float64{1}); !math.IsNaN(u) || p != 1 {
+		t.Errorf("MannWhitney(empty) = %v, %v, want NaN, 1", u, p)
+	}
+}
+
+func TestUDistribution(t *testing.T) {
+	// Arrangements of 2 and 3 values by U: 1 1 2 2 2 1 1 (sum C(5,2) = 10)
+	want := []float64{1, 1, 2, 2, 2, 1, 1}
+	got := uDistribution(2, 3)
+	if len(got) != len(want) {
+		t.Fatalf("uDistribution(2, 3) = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("uDistribution(2, 3) = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestBootstrap(t *testing.T) {
+	tests := []struct {
+		name string
+		xs   []float64
+	}{
+		{"constant", []float64{5, 5, 5, 5}},
+		{"spread", []float64{9, 10, 10, 11, 12, 10, 9, 30}},
+		{"single", []float64{3}},
+	}
+	for _, tt := range tests {
+		rng := rand.New(rand.NewSource(1))
+		lo, hi := Bootstrap(tt.xs, Median, DefaultIterations, 0.95, rng)
+		sorted := sortedCopy(tt.xs)
+		median := Median(tt.xs)
+		if lo > median || hi < median {
+			t.Errorf("%s: CI [%v, %v] excludes the median %v", tt.name, lo, hi, median)
+		}
+		if lo < sorted[0] || hi > sorted[len(sorted)-1] {
+			t.Errorf("%s: CI [%v, %v] outside the data", tt.name, lo, hi)
+		}
+	}
+
+	lo, hi := Bootstrap([]float64{5, 5, 5}, Median, 100, 0.95, rand.New(rand.NewSource(1)))
+	if lo != 5 || hi != 5 {
+		t.Errorf("Bootstrap(constant) = [%v, %v], want [5, 5]", lo, hi)
+	}
+	lo, hi = Bootstrap(nil, Median, 100, 0.95, rand.New(rand.NewSource(1)))
+	if !math.IsNaN(lo) || !math.IsNaN(hi) {
+		t.Errorf("Bootstrap(nil) = [%v, %v], want NaN", lo, hi)
+	}
+}
+
+func TestRatioCI(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      []float64
+		wantRatio float64
+		exact     bool // CI collapses to the ratio
+	}{
+		{"constant", []float64{4, 4, 4}, []float64{2, 2, 2}, 2, true},
+		{"spread", []float64{18, 20, 22, 20, 21}, []float64{9, 10, 11, 10, 10}, 2, false},
+	}
+	for _, tt := range tests {
+		ratio, lo, hi := RatioCI(tt.a, tt.b, DefaultIterations, 0.95, rand.New(rand.NewSource(1)))
+		if ratio != tt.wantRatio {
+			t.Errorf("%s: ratio = %v, want %v", tt.name, ratio, tt.wantRatio)
+		}
+		if lo > ratio || hi < ratio {
+			t.Errorf("%s: CI [%v, %v] excludes the ratio %v", tt.name, lo, hi, ratio)
+		}
+		if tt.exact && (lo != ratio || hi != ratio) {
+			t.Errorf("%s: CI [%v, %v], want [%v, %v]", tt.name, lo, hi, ratio, ratio)
+		}
+	}
+}
+
+// seq returns the values from..to.
+func seq(from, to int) []float64 {
+	var xs []float64
+	for i := from; i <= to; i++ {
+		xs = append(xs, float64(i))
+	}
+	return xs
+}
@@ -0,0 +1,197 @@
+// Package sweep expands parameter sweep specs into AWK variants.
+//
+// A spec is a product of terms separated by "×" or "*":
+//
+//	uawk × {posix,--no-posix} × GOGC={50,100,200,off}
+//
+// The first term names the AWKs to sweep ({uawk,goawk} for several).
+// The other terms are dimensions:
+//
+//	{posix,--no-posix}   flags: values starting with "-" are arguments,
+//	                     other values are labels for "no arguments"
+//	-j={1,2,4}           a flag with a value (-j 1, -j 2, -j 4)
+//	GOGC={50,100,off}    an environment variable
+//
+// A term without braces has a single level (e.g. GOGC=off).
+package sweep
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kolkov/uawk-bench/internal/runner"
+)
+
+// AWKParam is the parameter naming the swept AWK of a variant.
+const AWKParam = "awk"
+
+// Level is one value of a dimension.
+type Level struct {
+	Value string   // Recorded in AWK.Params under the dimension name
+	Label string   // Shown in variant names
+	Args  []string // Extra arguments
+	Env   []string // Extra environment variables
+}
+
+// Dimension is a parameter with the levels to sweep.
+type Dimension struct {
+	Name   string
+	Levels []Level
+}
+
+// Spec is a parsed sweep spec.
+type Spec struct {
+	AWKs       []string // Names of the AWKs to sweep
+	Dimensions []Dimension
+}
+
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Parse parses a sweep spec.
+func Parse(s string) (Spec, error) {
+	var spec Spec
+	terms := strings.FieldsFunc(s, func(r rune) bool { return r == '*' || r == '×' })
+	if len(terms) == 0 {
+		return spec, fmt.Errorf("empty sweep spec")
+	}
+
+	spec.AWKs = values(terms[0])
+	if len(spec.AWKs) == 0 {
+		return spec, fmt.Errorf("sweep %q: no AWK", s)
+	}
+
+	flagDims := 0
+	for _, term := range terms[1:] {
+		term = strings.TrimSpace(term)
+		key, list, hasKey := strings.Cut(term, "=")
+		if !hasKey || strings.HasPrefix(term, "{") {
+			key, list = "", term
+		}
+
+		var dim Dimension
+		switch {
+		case key == "":
+			flagDims++
+			dim.Name = "flags"
+			if flagDims > 1 {
+				dim.Name = fmt.Sprintf("flags%d", flagDims)
+			}
+			for _, v := range values(list) {
+				level := Level{Value: v, Label: v}
+				if strings.HasPrefix(v, "-") {
+					level.Args = strings.Fields(v)
+				}
+				dim.Levels = append(dim.Levels, level)
+			}
+		case strings.HasPrefix(key, "-"):
+			dim.Name = key
+			for _, v := range values(list) {
+				dim.Levels = append(dim.Levels, Level{Value: v, Label: key + " " + v, Args: []string{key, v}})
+			}
+		case envName.MatchString(key):
+			dim.Name = key
+			for _, v := range values(list) {
+				dim.Levels = append(dim.Levels, Level{Value: v, Label: key + "=" + v, Env: []string{key + "=" + v}})
+			}
+		default:
+			return spec, fmt.Errorf("sweep term %q: want {values}, -flag={values} or VAR={values}", term)
+		}
+		if len(dim.Levels) == 0 {
+			return spec, fmt.Errorf("sweep term %q has no values", term)
+		}
+		spec.Dimensions = append(spec.Dimensions, dim)
+	}
+	return spec, nil
+}
+
+// values splits "{a,b}" or "a" into its values.
+func values(s string) []string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+	var vs []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			vs = append(vs, v)
+		}
+	}
+	return vs
+}
+
+// Expand returns one AWK per combination of levels, in spec order. lookup
+// resolves the swept AWK names (e.g. to the built-in AWK list); levels add
+// arguments and environment variables. Variants are named after their
+// levels ("uawk --no-posix GOGC=50") and carry them in AWK.Params.
+func (s Spec) Expand(lookup func(name string) (runner.AWK, bool)) ([]runner.AWK, error) {
+	var variants []runner.AWK
+	for _, name := range s.AWKs {
+		base, ok := lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("sweep: unknown AWK %q", name)
+		}
+		base.Params = runner.Params{AWKParam: name}
+		variants = append(variants, s.expand(base, 0)...)
+	}
+	return variants, nil
+}
+
+// expand applies dimensions i... to awk.
+func (s Spec) expand(awk runner.AWK, i int) []runner.AWK {
+	if i == len(s.Dimensions) {
+		return []runner.AWK{awk}
+	}
+
+	dim := s.Dimensions[i]
+	var variants []runner.AWK
+	for _, level := range dim.Levels {
+		v := awk
+		v.Name = awk.Name + " " + level.Label
+		v.Args = append(append([]string{}, awk.Args...), level.Args...)
+		v.Env = append(append([]string{}, awk.Env...), level.Env...)
+		v.Params = make(runner.Params, len(awk.Params)+1)
+		for k, val := range awk.Params {
+			v.Params[k] = val
+		}
+		v.Params[dim.Name] = level.Value
+		variants = append(variants, s.expand(v, i+1)...)
+	}
+	return variants
+}
+
+// Scaling knobs: the dimension names Scaling sweeps, as Parse names them.
+const (
+	Workers    = "-j"         // uawk workers (-j N)
+	GOMAXPROCS = "GOMAXPROCS" // Go runtime threads
+)
+
+// Scaling returns a spec that runs awks with knob ("j" or "gomaxprocs")
+// at every value from 1 to max. Each variant's Parallelism is the knob
+// value, so with Runner.CPUs set it is pinned to as many CPUs.
+func Scaling(awks []string, knob string, max int) (Spec, error) {
+	if max < 1 {
+		return Spec{}, fmt.Errorf("invalid scaling maximum %d", max)
+	}
+	var dim Dimension
+	switch strings.ToLower(knob) {
+	case "j":
+		dim.Name = Workers
+	case "gomaxprocs":
+		dim.Name = GOMAXPROCS
+	default:
+		return Spec{}, fmt.Errorf("invalid scaling knob: %s (use j, gomaxprocs)", knob)
+	}
+	for n := 1; n <= max; n++ {
+		v := strconv.Itoa(n)
+		level := Level{Value: v}
+		if dim.Name == Workers {
+			level.Label = Workers + " " + v
+			level.Args = []string{Workers, v}
+		} else {
+			level.Label = GOMAXPROCS + "=" + v
+			level.Env = []string{GOMAXPROCS + "=" + v}
+		}
+		dim.Levels = append(dim.Levels, level)
+	}
+	return Spec{AWKs: awks, Dimensions: []Dimension{dim}}, nil
+}
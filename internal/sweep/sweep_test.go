@@ -0,0 +1,137 @@
+package sweep
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kolkov/uawk-bench/internal/runner"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Spec
+		wantErr bool
+	}{
+		{"uawk", Spec{AWKs: []string{"uawk"}}, false},
+		{"uawk × {posix,--no-posix}", Spec{
+			AWKs: []string{"uawk"},
+			Dimensions: []Dimension{{Name: "flags", Levels: []Level{
+				{Value: "posix", Label: "posix"},
+				{Value: "--no-posix", Label: "--no-posix", Args: []string{"--no-posix"}},
+			}}},
+		}, false},
+		{"{uawk, goawk} * -j={1,4} * GOGC=off", Spec{
+			AWKs: []string{"uawk", "goawk"},
+			Dimensions: []Dimension{
+				{Name: "-j", Levels: []Level{
+					{Value: "1", Label: "-j 1", Args: []string{"-j", "1"}},
+					{Value: "4", Label: "-j 4", Args: []string{"-j", "4"}},
+				}},
+				{Name: "GOGC", Levels: []Level{
+					{Value: "off", Label: "GOGC=off", Env: []string{"GOGC=off"}},
+				}},
+			},
+		}, false},
+		{"gawk × {-b,-c} × {default,--posix}", Spec{
+			AWKs: []string{"gawk"},
+			Dimensions: []Dimension{
+				{Name: "flags", Levels: []Level{
+					{Value: "-b", Label: "-b", Args: []string{"-b"}},
+					{Value: "-c", Label: "-c", Args: []string{"-c"}},
+				}},
+				{Name: "flags2", Levels: []Level{
+					{Value: "default", Label: "default"},
+					{Value: "--posix", Label: "--posix", Args: []string{"--posix"}},
+				}},
+			},
+		}, false},
+		{"", Spec{}, true},
+		{"{} × GOGC=off", Spec{}, true},
+		{"uawk × GOGC={}", Spec{}, true},
+		{"uawk × 1GOGC={50}", Spec{}, true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, want error %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	builtin := map[string]runner.AWK{
+		"uawk":  {Name: "uawk", Command: "uawk", Env: []string{"A=1"}},
+		"goawk": {Name: "goawk", Command: "goawk", Args: []string{"-x"}},
+	}
+	lookup := func(name string) (runner.AWK, bool) {
+		awk, ok := builtin[name]
+		return awk, ok
+	}
+
+	tests := []struct {
+		spec    string
+		want    []runner.AWK
+		wantErr bool
+	}{
+		{"uawk", []runner.AWK{
+			{Name: "uawk", Command: "uawk", Env: []string{"A=1"}, Params: runner.Params{"awk": "uawk"}},
+		}, false},
+		{"{uawk,goawk} × {posix,--no-posix} × GOGC={50,off}", []runner.AWK{
+			{Name: "uawk posix GOGC=50", Command: "uawk", Args: []string{}, Env: []string{"A=1", "GOGC=50"},
+				Params: runner.Params{"awk": "uawk", "flags": "posix", "GOGC": "50"}},
+			{Name: "uawk posix GOGC=off", Command: "uawk", Args: []string{}, Env: []string{"A=1", "GOGC=off"},
+				Params: runner.Params{"awk": "uawk", "flags": "posix", "GOGC": "off"}},
+			{Name: "uawk --no-posix GOGC=50", Command: "uawk", Args: []string{"--no-posix"}, Env: []string{"A=1", "GOGC=50"},
+				Params: runner.Params{"awk": "uawk", "flags": "--no-posix", "GOGC": "50"}},
+			{Name: "uawk --no-posix GOGC=off", Command: "uawk", Args: []string{"--no-posix"}, Env: []string{"A=1", "GOGC=off"},
+				Params: runner.Params{"awk": "uawk", "flags": "--no-posix", "GOGC": "off"}},
+			{Name: "goawk posix GOGC=50", Command: "goawk", Args: []string{"-x"}, Env: []string{"GOGC=50"},
+				Params: runner.Params{"awk": "goawk", "flags": "posix", "GOGC": "50"}},
+			{Name: "goawk posix GOGC=off", Command: "goawk", Args: []string{"-x"}, Env: []string{"GOGC=off"},
+				Params: runner.Params{"awk": "goawk", "flags": "posix", "GOGC": "off"}},
+			{Name: "goawk --no-posix GOGC=50", Command: "goawk", Args: []string{"-x", "--no-posix"}, Env: []string{"GOGC=50"},
+				Params: runner.Params{"awk": "goawk", "flags": "--no-posix", "GOGC": "50"}},
+			{Name: "goawk --no-posix GOGC=off", Command: "goawk", Args: []string{"-x", "--no-posix"}, Env: []string{"GOGC=off"},
+				Params: runner.Params{"awk": "goawk", "flags": "--no-posix", "GOGC": "off"}},
+		}, false},
+		{"mawk × GOGC=off", nil, true},
+	}
+	for _, tt := range tests {
+		spec, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.spec, err)
+		}
+		got, err := spec.Expand(lookup)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Expand(%q) error = %v, want error %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Expand(%q) =\n%+v\nwant\n%+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestExpandCopies(t *testing.T) {
+	// Levels must not share the base AWK's slices
+	base := runner.AWK{Name: "uawk", Args: make([]string, 1, 8), Env: make([]string, 0, 8)}
+	spec, err := Parse("uawk × {-a,-b} × X={1,2}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := spec.Expand(func(string) (runner.AWK, bool) { return base, true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"", "-a"}, {"", "-a"}, {"", "-b"}, {"", "-b"}}
+	for i, awk := range got {
+		if !reflect.DeepEqual(awk.Args, want[i]) {
+			t.Errorf("variant %d (%s): Args = %q, want %q", i, awk.Name, awk.Args, want[i])
+		}
+	}
+}
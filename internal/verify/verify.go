@@ -0,0 +1,167 @@
+// Package verify checks that AWK implementations produce the expected output
+// before their timings are trusted.
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kolkov/uawk-bench/internal/compare"
+	"github.com/kolkov/uawk-bench/internal/runner"
+)
+
+// Verifier runs every AWK once per program and compares its output
+// against a reference: a golden file if one exists, otherwise the
+// output of the reference AWK.
+//
+// Programs compared byte for byte are checked by digest, so outputs are
+// never held in memory; programs with canonicalizing rules (see package
+// compare) are captured in full.
+type Verifier struct {
+	Runner    *runner.Runner
+	Reference *runner.AWK // Reference implementation (nil = golden files only)
+	GoldenDir string      // Directory with golden outputs (empty = none)
+}
+
+// output is a program output as seen by the verifier.
+type output struct {
+	text   string // Full output, or a prefix when only the digest matters
+	digest runner.Digest
+}
+
+// Check runs each AWK on the program and returns the verification error
+// for every AWK whose output differs from the reference. Outputs are
+// compared under the program's rules (see package compare). The returned
+// string names the reference that was used ("golden" or an AWK name).
+func (v *Verifier) Check(ctx context.Context, awks []runner.AWK, programFile string, inputFiles ...string) (map[string]error, string, error) {
+	rules, err := compare.Load(programFile)
+	if err != nil {
+		return nil, "", err
+	}
+	capture := !rules.Exact()
+
+	want, source, err := v.expected(ctx, programFile, inputFiles, capture)
+	if err != nil {
+		return nil, "", err
+	}
+
+	failures := make(map[string]error)
+	for _, awk := range awks {
+		result := v.run(ctx, awk, programFile, inputFiles, capture)
+		if result.Error != nil {
+			failures[awk.Name] = result.Error
+			continue
+		}
+		got := output{text: result.Output, digest: result.Digest}
+		if err := match(rules, want, got); err != nil {
+			failures[awk.Name] = err
+		}
+	}
+	return failures, source, nil
+}
+
+// run executes an AWK, capturing its full output only if required.
+func (v *Verifier) run(ctx context.Context, awk runner.AWK, programFile string, inputFiles []string, capture bool) runner.Result {
+	if capture {
+		return v.Runner.RunCapture(ctx, awk, programFile, inputFiles...)
+	}
+	return v.Runner.Run(ctx, awk, programFile, inputFiles...)
+}
+
+// expected returns the reference output for a program.
+func (v *Verifier) expected(ctx context.Context, programFile string, inputFiles []string, capture bool) (output, string, error) {
+	if v.GoldenDir != "" {
+		want, err := readGolden(GoldenPath(v.GoldenDir, programFile), v.limit(capture))
+		if err == nil {
+			return want, "golden", nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return output{}, "", err
+		}
+	}
+
+	if v.Reference == nil {
+		return output{}, "", errors.New("no golden file and no reference AWK")
+	}
+	result := v.run(ctx, *v.Reference, programFile, inputFiles, capture)
+	if result.Error != nil {
+		return output{}, "", fmt.Errorf("reference %s: %w", v.Reference.Name, result.Error)
+	}
+	return output{text: result.Output, digest: result.Digest}, v.Reference.Name, nil
+}
+
+// limit returns the sink limit for reference outputs.
+func (v *Verifier) limit(capture bool) int {
+	if capture {
+		return -1
+	}
+	return v.Runner.OutputLimit
+}
+
+// GoldenPath returns the golden output file for program in dir.
+// Golden files are named after the program with a .out extension
+// (e.g. sum.awk -> sum.out).
+func GoldenPath(dir, program string) string {
+	name := strings.TrimSuffix(filepath.Base(program), ".awk") + ".out"
+	return filepath.Join(dir, name)
+}
+
+// readGolden streams a golden file through a sink.
+func readGolden(path string, limit int) (output, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return output{}, err
+	}
+	defer f.Close()
+
+	sink := runner.NewSink(limit)
+	if _, err := io.Copy(sink, f); err != nil {
+		return output{}, err
+	}
+	return output{text: sink.Output(), digest: sink.Digest()}, nil
+}
+
+// match compares an output against the reference.
+func match(rules compare.Rules, want, got output) error {
+	if !rules.Exact() {
+		return rules.Diff(want.text, got.text)
+	}
+	if want.digest == got.digest {
+		return nil
+	}
+
+	// Point at the first difference if it falls within the kept prefixes
+	if i := firstDiff(want.text, got.text); i >= 0 {
+		line := strings.Count(want.text[:i], "\n") + 1
+		return fmt.Errorf("line %d: want %q, got %q", line, compare.Clip(lineAt(want.text, i)), compare.Clip(lineAt(got.text, i)))
+	}
+	return fmt.Errorf("output has %d bytes, %d lines (sha256 %.12s); want %d bytes, %d lines (sha256 %.12s)",
+		got.digest.Bytes, got.digest.Lines, got.digest.SHA256,
+		want.digest.Bytes, want.digest.Lines, want.digest.SHA256)
+}
+
+// firstDiff returns the index of the first differing byte of a and b,
+// or -1 if one is a prefix of the other.
+func firstDiff(a, b string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+// lineAt returns the (possibly truncated) line of s containing index i.
+func lineAt(s string, i int) string {
+	start := strings.LastIndexByte(s[:i], '\n') + 1
+	end := strings.IndexByte(s[i:], '\n')
+	if end < 0 {
+		return s[start:]
+	}
+	return s[start : i+end]
+}
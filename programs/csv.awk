@@ -1,6 +1,7 @@
 # CSV field sum (comma-separated)
 # Input: CSV file
 # Measures: non-default FS handling
+# Verify: tolerance=1e-5
 BEGIN { FS = "," }
 { sum += $3 }
 END { print sum }
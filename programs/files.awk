@@ -0,0 +1,5 @@
+# File and line count
+# Input: many small files (-layouts small)
+# Measures: per-file overhead with minimal per-record work
+FNR == 1 { files++ }
+END { print files, NR }
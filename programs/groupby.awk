@@ -1,5 +1,6 @@
 # Group by key and aggregate
 # Input: key-value data (col1=key, col2=value)
 # Measures: associative arrays
+# Verify: sort tolerance=1e-5
 { count[$1]++; sum[$1] += $2 }
 END { for (k in count) print k, sum[k]/count[k] }
@@ -0,0 +1,12 @@
+# Per-file line counts
+# Input: many small files (-layouts small)
+# Measures: per-file overhead (open, FNR reset, FILENAME update)
+FNR == 1 && NR > 1 { report() }
+{ name = FILENAME; n = FNR }
+END { if (NR > 0) report() }
+
+function report(base) {
+    base = name
+    sub(/.*\//, "", base)
+    print base, n
+}
@@ -1,5 +1,6 @@
 # Sum numeric columns
 # Input: numeric data with whitespace-separated fields
 # Measures: field parsing + numeric operations
+# Verify: tolerance=1e-5
 { sum1 += $1; sum2 += $2 }
 END { print sum1, sum2 }
@@ -1,6 +1,7 @@
 # Word frequency count
 # Input: text file
 # Measures: split + associative arrays + sorting
+# Verify: sort
 {
     for (i = 1; i <= NF; i++)
         words[tolower($i)]++